@@ -0,0 +1,27 @@
+package gee
+
+import "golang.org/x/crypto/acme/autocert"
+
+// RunAutoTLS serves HTTPS on :443 with certificates for domains obtained from
+// Let's Encrypt and cached in cacheDir. It also listens on :80 to answer
+// HTTP-01 challenges and redirect everything else to HTTPS.
+func (engine *Engine) RunAutoTLS(domains []string, cacheDir string) error {
+	if err := engine.start(); err != nil {
+		return err
+	}
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+	}
+
+	// HTTPHandler(nil) 处理 ACME 验证请求，其余请求重定向到 https
+	redirect := engine.newServer(":http")
+	redirect.Handler = m.HTTPHandler(nil)
+	srv := engine.newServer(":https")
+	srv.TLSConfig = secureTLSConfig(m.TLSConfig())
+
+	return engine.serveGroup(redirect.ListenAndServe, func() error {
+		return srv.ListenAndServeTLS("", "")
+	})
+}
@@ -0,0 +1,475 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultMultipartMemory 是解析 multipart 表单时放在内存里的上限，超出部分写临时文件
+const defaultMultipartMemory = 32 << 20
+
+// Bind decodes the request into obj like ShouldBind. On failure it responds
+// 400 (413 for bodies over MaxRequestBodySize), records the error with
+// c.Error and aborts the chain.
+func (c *Context) Bind(obj interface{}) error {
+	return c.bindOrFail(c.ShouldBind(obj))
+}
+
+// bindOrFail 把绑定错误转成 400 响应，请求体超过限制时是 413
+func (c *Context) bindOrFail(err error) error {
+	if err == nil {
+		return nil
+	}
+	code := http.StatusBadRequest
+	if IsBodyTooLarge(err) {
+		code = http.StatusRequestEntityTooLarge
+	}
+	c.Error(NewError(code, err.Error()).WithInternal(err))
+	c.failNegotiated(code, err.Error())
+	return err
+}
+
+// ShouldBind decodes the request into obj, a pointer to a struct, choosing
+// the decoder from the Content-Type: JSON bodies with ShouldBindJSON, form
+// bodies with ShouldBindForm, protobuf bodies into a proto.Message with
+// ShouldBindProtoBuf, and requests without a body with
+// ShouldBindQuery. Fields tagged binding:"required" must be set.
+func (c *Context) ShouldBind(obj interface{}) error {
+	if c.Req.Body == nil || c.Req.Body == http.NoBody || c.Req.Method == http.MethodGet {
+		return c.ShouldBindQuery(obj)
+	}
+	ctype, _, _ := mime.ParseMediaType(c.Req.Header.Get("Content-Type"))
+	switch ctype {
+	case "application/json":
+		return c.ShouldBindJSON(obj)
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		return c.ShouldBindForm(obj)
+	case MIMEProtoBuf:
+		if msg, ok := obj.(proto.Message); ok {
+			return c.ShouldBindProtoBuf(msg)
+		}
+	}
+	return fmt.Errorf("gee: cannot bind Content-Type %q", ctype)
+}
+
+// ShouldBindJSON decodes the JSON body into obj and checks the binding
+// rules.
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	if c.Req.Body == nil {
+		return errors.New("gee: empty request body")
+	}
+	if err := json.NewDecoder(c.Req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return validate(reflect.ValueOf(obj), "")
+}
+
+// ShouldBindQuery binds the URL query into obj. Keys are matched against
+// the form tag (or the field name) and may address nested values:
+// filter.name=x and filter[name]=x fill a nested struct or map, while
+// ids=1&ids=2, ids[]=1&ids[]=2 and items[0].name=x fill slices.
+//
+// A default tag gives the value of a missing key, e.g. default:"1" for a
+// page number. time.Time fields are parsed with the layout in time_format
+// (RFC 3339 by default, or unix, unixmilli, unixnano), in the time_location
+// zone, UTC with time_utc:"true", or the local zone.
+func (c *Context) ShouldBindQuery(obj interface{}) error {
+	return bindValues(obj, c.Req.URL.Query())
+}
+
+// ShouldBindForm binds the query and the url-encoded or multipart form
+// body into obj, with the same key syntax as ShouldBindQuery.
+func (c *Context) ShouldBindForm(obj interface{}) error {
+	if err := c.Req.ParseMultipartForm(defaultMultipartMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return err
+	}
+	return bindValues(obj, c.Req.Form)
+}
+
+func bindValues(obj interface{}, values url.Values) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("gee: bind target must be a non-nil pointer")
+	}
+	root := newFormNode()
+	for key, vals := range values {
+		root.insert(splitFormKey(key), vals)
+	}
+	if err := bindNode(v.Elem(), root, "", ""); err != nil {
+		return err
+	}
+	return validate(v, "")
+}
+
+// formNode 是按 . 和 [] 拆开的表单键组成的树
+type formNode struct {
+	values   []string
+	children map[string]*formNode
+}
+
+func newFormNode() *formNode {
+	return &formNode{children: make(map[string]*formNode)}
+}
+
+func (n *formNode) insert(path []string, values []string) {
+	for _, name := range path {
+		child, ok := n.children[name]
+		if !ok {
+			child = newFormNode()
+			n.children[name] = child
+		}
+		n = child
+	}
+	n.values = append(n.values, values...)
+}
+
+// splitFormKey 把 a.b[c][]、items[0].name 拆成 [a b c]、[items 0 name]，空的 [] 表示追加。
+// 方括号里的内容原样作为一段，所以 meta[a.b] 的键是 a.b
+func splitFormKey(key string) []string {
+	var parts []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			parts = append(parts, cur.String())
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '.':
+			flush()
+		case '[':
+			end := strings.IndexByte(key[i:], ']')
+			if end < 0 {
+				cur.WriteString(key[i:])
+				i = len(key)
+				continue
+			}
+			flush()
+			cur.WriteString(key[i+1 : i+end])
+			flush()
+			i += end
+		default:
+			cur.WriteByte(key[i])
+		}
+	}
+	flush()
+	return parts
+}
+
+var timeReflectType = reflect.TypeOf(time.Time{})
+
+func bindNode(v reflect.Value, n *formNode, path string, tag reflect.StructTag) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			if !v.CanSet() {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return bindNode(v.Elem(), n, path, tag)
+	case reflect.Struct:
+		if v.Type() == timeReflectType {
+			break
+		}
+		return bindStruct(v, n, path)
+	case reflect.Slice:
+		return bindSlice(v, n, path, tag)
+	case reflect.Map:
+		return bindMap(v, n, path, tag)
+	}
+	if len(n.values) == 0 {
+		return nil
+	}
+	if err := setScalar(v, n.values[0], tag); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+func bindStruct(v reflect.Value, n *formNode, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, ok := formName(f)
+		if !ok {
+			continue
+		}
+		// 未导出的嵌入字段本身不能设置，只有结构体还能通过它的导出字段绑定
+		if ft := indirectType(f.Type); !v.Field(i).CanSet() && (ft.Kind() != reflect.Struct || ft == timeReflectType) {
+			continue
+		}
+		// 没有 form 标签的嵌入结构体，字段展开到外层
+		if f.Anonymous && f.Tag.Get("form") == "" && indirectType(f.Type).Kind() == reflect.Struct {
+			if err := bindNode(v.Field(i), n, path, f.Tag); err != nil {
+				return err
+			}
+			continue
+		}
+		child, ok := n.children[name]
+		if !ok {
+			// 请求里没有这个键时使用 default 标签的值，切片用逗号分隔多个值
+			def, hasDefault := f.Tag.Lookup("default")
+			if !hasDefault {
+				continue
+			}
+			child = &formNode{values: []string{def}}
+			if indirectType(f.Type).Kind() == reflect.Slice {
+				child.values = strings.Split(def, ",")
+			}
+		}
+		if err := bindNode(v.Field(i), child, joinPath(path, name), f.Tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bindSlice(v reflect.Value, n *formNode, path string, tag reflect.StructTag) error {
+	// ids=1&ids=2 或 ids[]=1 这样直接给值
+	if len(n.values) > 0 {
+		s := reflect.MakeSlice(v.Type(), len(n.values), len(n.values))
+		for i, val := range n.values {
+			if err := bindNode(s.Index(i), &formNode{values: []string{val}}, fmt.Sprintf("%s[%d]", path, i), tag); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	// items[0].name=x 这样按下标给值
+	indexes := make([]int, 0, len(n.children))
+	for key := range n.children {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 {
+			return fmt.Errorf("%s: invalid index %q", path, key)
+		}
+		indexes = append(indexes, i)
+	}
+	if len(indexes) == 0 {
+		return nil
+	}
+	sort.Ints(indexes)
+	// 下标可能不连续，按顺序压缩成连续的切片，防止 items[1000000] 分配过大的内存
+	s := reflect.MakeSlice(v.Type(), len(indexes), len(indexes))
+	for i, index := range indexes {
+		key := strconv.Itoa(index)
+		if err := bindNode(s.Index(i), n.children[key], fmt.Sprintf("%s[%d]", path, index), tag); err != nil {
+			return err
+		}
+	}
+	v.Set(s)
+	return nil
+}
+
+func bindMap(v reflect.Value, n *formNode, path string, tag reflect.StructTag) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("%s: map keys must be strings", path)
+	}
+	if len(n.children) == 0 {
+		return nil
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(v.Type(), len(n.children)))
+	}
+	for key, child := range n.children {
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if err := bindNode(elem, child, joinPath(path, key), tag); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+	}
+	return nil
+}
+
+func setScalar(v reflect.Value, s string, tag reflect.StructTag) error {
+	if v.Type() == timeReflectType {
+		if s == "" {
+			return nil
+		}
+		t, err := parseTime(s, tag)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "" || s == "on" {
+			// 复选框勾选时浏览器发送 on
+			v.SetBool(s == "on")
+			return nil
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// parseTime 按 time_format 标签解析时间，默认 RFC 3339。
+// time_format 可以是 Go 的时间布局，也可以是 unix、unixmilli、unixnano 时间戳；
+// 没有时区的布局按 time_location 标签的时区解析，time_utc:"true" 表示 UTC，都没有时用本地时区
+func parseTime(s string, tag reflect.StructTag) (time.Time, error) {
+	format := tag.Get("time_format")
+	switch format {
+	case "":
+		return time.Parse(time.RFC3339, s)
+	case "unix", "unixmilli", "unixnano":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch format {
+		case "unix":
+			return time.Unix(n, 0), nil
+		case "unixmilli":
+			return time.UnixMilli(n), nil
+		}
+		return time.Unix(0, n), nil
+	}
+	loc := time.Local
+	if utc, _ := strconv.ParseBool(tag.Get("time_utc")); utc {
+		loc = time.UTC
+	} else if name := tag.Get("time_location"); name != "" {
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc = l
+	}
+	return time.ParseInLocation(format, s, loc)
+}
+
+// formName 返回字段在表单里的名字，form:"-" 和未导出的字段不绑定
+func formName(f reflect.StructField) (string, bool) {
+	if f.PkgPath != "" && !f.Anonymous {
+		return "", false
+	}
+	tag := f.Tag.Get("form")
+	if tag == "-" {
+		return "", false
+	}
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name, true
+	}
+	return f.Name, true
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// validate 检查 binding:"required" 的字段不是零值，嵌套的结构体和切片元素也会检查
+func validate(v reflect.Value, path string) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == timeReflectType {
+			return nil
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				continue
+			}
+			name := joinPath(path, fieldLabel(f))
+			for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+				if rule == "required" && v.Field(i).IsZero() {
+					return fmt.Errorf("%s is required", name)
+				}
+			}
+			if f.Anonymous {
+				name = path
+			}
+			if err := validate(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validate(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validate(iter.Value(), joinPath(path, fmt.Sprint(iter.Key()))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldLabel 是错误信息里的字段名，优先用 form 标签，其次 json 标签
+func fieldLabel(f reflect.StructField) string {
+	for _, key := range []string{"form", "json"} {
+		if name := strings.Split(f.Tag.Get(key), ",")[0]; name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
@@ -0,0 +1,146 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindFilter struct {
+	Name string `form:"name" binding:"required"`
+	Tags []string
+}
+
+type bindItem struct {
+	ID  int    `form:"id"`
+	SKU string `form:"sku"`
+}
+
+type bindQuery struct {
+	Page   int               `form:"page"`
+	IDs    []int             `form:"ids"`
+	Filter bindFilter        `form:"filter"`
+	Meta   map[string]string `form:"meta"`
+	Items  []bindItem        `form:"items"`
+	Owner  *bindItem         `form:"owner"`
+}
+
+func TestSplitFormKey(t *testing.T) {
+	cases := map[string][]string{
+		"page":           {"page"},
+		"filter.name":    {"filter", "name"},
+		"filter[name]":   {"filter", "name"},
+		"ids[]":          {"ids"},
+		"items[0].sku":   {"items", "0", "sku"},
+		"items[0][sku]":  {"items", "0", "sku"},
+		"meta[a.b]":      {"meta", "a.b"},
+		"filter[Tags][]": {"filter", "Tags"},
+	}
+	for key, want := range cases {
+		if got := splitFormKey(key); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitFormKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestShouldBindQuery(t *testing.T) {
+	query := "page=2&ids[]=1&ids[]=2&filter.name=gee&filter[Tags]=a&filter[Tags]=b" +
+		"&meta[lang]=go&items[1].sku=B&items[0][id]=7&items[0][sku]=A&owner.id=3"
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	c, _ := CreateTestContext(httptest.NewRecorder(), req)
+
+	var q bindQuery
+	if err := c.ShouldBindQuery(&q); err != nil {
+		t.Fatal(err)
+	}
+	want := bindQuery{
+		Page:   2,
+		IDs:    []int{1, 2},
+		Filter: bindFilter{Name: "gee", Tags: []string{"a", "b"}},
+		Meta:   map[string]string{"lang": "go"},
+		Items:  []bindItem{{ID: 7, SKU: "A"}, {SKU: "B"}},
+		Owner:  &bindItem{ID: 3},
+	}
+	if !reflect.DeepEqual(q, want) {
+		t.Fatalf("bound %+v, want %+v", q, want)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?page=x&filter.name=gee", nil)
+	c, _ := CreateTestContext(httptest.NewRecorder(), req)
+	var q bindQuery
+	if err := c.ShouldBindQuery(&q); err == nil || !strings.Contains(err.Error(), "page") {
+		t.Fatalf("invalid integer: %v", err)
+	}
+
+	form := url.Values{"page": {"1"}}
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	c, _ = CreateTestContext(w, req)
+	q = bindQuery{}
+	if err := c.Bind(&q); err == nil || err.Error() != "filter.name is required" {
+		t.Fatalf("required: %v", err)
+	}
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Bind should respond 400, got %d", w.Code)
+	}
+}
+
+func TestBindTimeAndDefault(t *testing.T) {
+	type search struct {
+		Page    int       `form:"page" default:"1"`
+		Sort    []string  `form:"sort" default:"name,id"`
+		Day     time.Time `form:"day" time_format:"2006-01-02" time_utc:"true"`
+		Since   time.Time `form:"since" time_format:"unix"`
+		Created time.Time `form:"created"`
+	}
+	req := httptest.NewRequest(http.MethodGet, "/?day=2023-05-01&since=1700000000&created=2023-05-01T08:00:00Z", nil)
+	c, _ := CreateTestContext(httptest.NewRecorder(), req)
+
+	var s search
+	if err := c.ShouldBindQuery(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Page != 1 || !reflect.DeepEqual(s.Sort, []string{"name", "id"}) {
+		t.Fatalf("defaults: page=%d sort=%v", s.Page, s.Sort)
+	}
+	if !s.Day.Equal(time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)) || s.Day.Location() != time.UTC {
+		t.Fatalf("day = %v", s.Day)
+	}
+	if s.Since.Unix() != 1700000000 {
+		t.Fatalf("since = %v", s.Since)
+	}
+	if !s.Created.Equal(time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("created = %v", s.Created)
+	}
+}
+
+type bindCount int
+
+type bindPaging struct {
+	Page int `form:"page"`
+}
+
+func TestBindUnexportedEmbedded(t *testing.T) {
+	type search struct {
+		bindCount
+		bindPaging
+		Q string `form:"q"`
+	}
+	req := httptest.NewRequest(http.MethodGet, "/?bindCount=3&page=2&q=gee", nil)
+	c, _ := CreateTestContext(httptest.NewRecorder(), req)
+
+	var s search
+	if err := c.ShouldBindQuery(&s); err != nil {
+		t.Fatal(err)
+	}
+	if s.bindCount != 0 || s.Page != 2 || s.Q != "gee" {
+		t.Fatalf("bound %+v", s)
+	}
+}
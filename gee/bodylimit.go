@@ -0,0 +1,62 @@
+package gee
+
+import (
+	"net/http"
+	"strings"
+)
+
+// NoBodyLimit passed to MaxRequestBodySize lifts an inherited limit, e.g.
+// for an upload group under an engine-wide limit.
+const NoBodyLimit = -1
+
+// ErrRequestEntityTooLarge is the error for bodies over the limit set with
+// MaxRequestBodySize.
+var ErrRequestEntityTooLarge = NewError(http.StatusRequestEntityTooLarge, "")
+
+// MaxRequestBodySize limits request bodies of routes in this group and its
+// subgroups to n bytes. Set on the engine it applies to every route; a
+// group's own limit overrides its parent's. Requests declaring a larger
+// Content-Length get 413 before any route middleware runs; longer chunked
+// bodies fail when read with an error for which IsBodyTooLarge is true.
+func (group *RouterGroup) MaxRequestBodySize(n int64) {
+	group.maxBodySize = n
+}
+
+// maxBodySize 返回请求所属分组的限制
+func (c *Context) maxBodySize() int64 {
+	return c.group.bodyLimit()
+}
+
+// bodyLimit 从分组向上找最近设置的限制，0 表示没有设置，负数表示不限制
+func (group *RouterGroup) bodyLimit() int64 {
+	for g := group; g != nil; g = g.parent {
+		if g.maxBodySize != 0 {
+			return g.maxBodySize
+		}
+	}
+	return 0
+}
+
+// limitBody 给请求体套上 MaxBytesReader，Content-Length 已经超限时返回 false
+func (c *Context) limitBody() bool {
+	n := c.maxBodySize()
+	if n <= 0 || c.Req.Body == nil || c.Req.Body == http.NoBody {
+		return true
+	}
+	if c.Req.ContentLength > n {
+		return false
+	}
+	c.Req.Body = http.MaxBytesReader(c.Writer, c.Req.Body, n)
+	return true
+}
+
+// IsBodyTooLarge reports whether err came from reading a request body
+// beyond the MaxRequestBodySize limit.
+func IsBodyTooLarge(err error) bool {
+	// http.MaxBytesError 从 Go 1.19 才有，这里只能比较错误信息
+	return err != nil && strings.Contains(err.Error(), "http: request body too large")
+}
+
+func bodyTooLargeHandler(c *Context) {
+	c.failNegotiated(http.StatusRequestEntityTooLarge, "request body too large")
+}
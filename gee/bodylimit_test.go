@@ -0,0 +1,52 @@
+package gee
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMaxRequestBodySize(t *testing.T) {
+	r := New()
+	r.MaxRequestBodySize(8)
+	read := func(c *Context) {
+		if _, err := io.ReadAll(c.Req.Body); err != nil {
+			if IsBodyTooLarge(err) {
+				c.Fail(http.StatusRequestEntityTooLarge, err.Error())
+				return
+			}
+			c.Fail(http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	r.POST("/small", read)
+	upload := r.Group("/upload")
+	upload.MaxRequestBodySize(NoBodyLimit)
+	upload.POST("/file", read)
+
+	cases := []struct {
+		path    string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"/small", "12345678", false, http.StatusOK},
+		{"/small", "123456789", false, http.StatusRequestEntityTooLarge},
+		{"/small", "123456789", true, http.StatusRequestEntityTooLarge},
+		{"/upload/file", strings.Repeat("x", 100), false, http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.path, strings.NewReader(tc.body))
+		if tc.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s with %d bytes: status = %d, want %d", tc.path, len(tc.body), w.Code, tc.want)
+		}
+	}
+}
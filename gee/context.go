@@ -2,19 +2,25 @@ package gee
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
+	"net"
 	"net/http"
+	"strings"
 )
 
 type H map[string]interface{}
 
 type Context struct {
-	Writer http.ResponseWriter
+	Writer ResponseWriter
 	Req    *http.Request
 	Path   string
 	Method string
 	// 存储解析后的参数，以便后续的处理函数可以方便地访问这些参数。
-	Params     map[string]string
+	Params map[string]string
+	// fullPath 是匹配到的路由模式，如 /hello/:name
+	fullPath   string
 	StatusCode int
 	handlers   []HandlerFunc
 	// index是记录当前执行到第几个中间件，
@@ -22,15 +28,49 @@ type Context struct {
 	// 然后再从后往前，调用每个中间件在Next方法之后定义的部分。
 	index  int
 	engine *Engine
+	// group 是请求匹配到的路由分组，用于查找分组级别的错误处理
+	group *RouterGroup
+	// Keys 保存本次请求中中间件之间共享的数据
+	Keys map[string]interface{}
+	// Errors 是处理过程中通过 c.Error 记录的错误
+	Errors []error
+	// writer 是最外层的 ResponseWriter，中间件替换 c.Writer 后仍能找到它
+	writer *responseWriter
+	// finish 在响应结束后按注册的逆序执行
+	finish []func()
+	// forwards 是 HandleContext 内部转发的次数
+	forwards int
 }
 
 func newContext(w http.ResponseWriter, req *http.Request) *Context {
+	writer := newResponseWriter(w)
 	return &Context{
-		Writer: w,
+		Writer: writer,
 		Req:    req,
 		Path:   req.URL.Path,
 		Method: req.Method,
 		index:  -1,
+		writer: writer,
+	}
+}
+
+// OnBeforeWrite registers fn to run right before the response header is
+// sent, the last moment headers such as cookies or ETag can be changed.
+func (c *Context) OnBeforeWrite(fn func()) {
+	c.writer.beforeWrite = append(c.writer.beforeWrite, fn)
+}
+
+// OnFinish registers fn to run after the response has been written, in
+// reverse order of registration like defer. The hooks also run when a
+// handler panics.
+func (c *Context) OnFinish(fn func()) {
+	c.finish = append(c.finish, fn)
+}
+
+// runFinish 按注册的逆序执行 OnFinish 注册的函数，由调用方 defer 执行，处理函数 panic 时也不会漏掉
+func (c *Context) runFinish() {
+	for i := len(c.finish) - 1; i >= 0; i-- {
+		c.finish[i]()
 	}
 }
 
@@ -42,6 +82,29 @@ func (c *Context) Next() {
 	}
 }
 
+// Set stores a value on the context for later handlers in the chain.
+func (c *Context) Set(key string, value interface{}) {
+	if c.Keys == nil {
+		c.Keys = make(map[string]interface{})
+	}
+	c.Keys[key] = value
+}
+
+// Get returns the value stored under key by Set.
+func (c *Context) Get(key string) (value interface{}, ok bool) {
+	value, ok = c.Keys[key]
+	return
+}
+
+// GetString returns the value stored under key if it is a string.
+func (c *Context) GetString(key string) string {
+	if v, ok := c.Get(key); ok {
+		s, _ := v.(string)
+		return s
+	}
+	return ""
+}
+
 func (c *Context) PostForm(key string) string {
 	return c.Req.FormValue(key)
 }
@@ -50,6 +113,38 @@ func (c *Context) Query(key string) string {
 	return c.Req.URL.Query().Get(key)
 }
 
+// ClientIP returns the client address. Forwarding headers are only honored
+// when the request comes from a proxy set by Engine.SetTrustedProxies.
+func (c *Context) ClientIP() string {
+	remoteIP, _, err := net.SplitHostPort(strings.TrimSpace(c.Req.RemoteAddr))
+	if err != nil {
+		remoteIP = strings.TrimSpace(c.Req.RemoteAddr)
+	}
+	if c.engine == nil || c.engine.trustedProxies == nil || !c.engine.trustedProxies.contains(net.ParseIP(remoteIP)) {
+		return remoteIP
+	}
+	if xff := c.Req.Header.Get("X-Forwarded-For"); xff != "" {
+		// 从右往左找第一个不是可信代理的地址，最左边的地址可以被客户端伪造
+		ips := strings.Split(xff, ",")
+		for i := len(ips) - 1; i >= 0; i-- {
+			ip := strings.TrimSpace(ips[i])
+			if i == 0 || !c.engine.trustedProxies.contains(net.ParseIP(ip)) {
+				return ip
+			}
+		}
+	}
+	if ip := strings.TrimSpace(c.Req.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	return remoteIP
+}
+
+// FullPath returns the matched route pattern, e.g. "/hello/:name", or ""
+// when no route matched.
+func (c *Context) FullPath() string {
+	return c.fullPath
+}
+
 func (c *Context) Param(key string) string {
 	value, _ := c.Params[key]
 	return value
@@ -64,6 +159,32 @@ func (c *Context) SetHeader(key string, value string) {
 	c.Writer.Header().Set(key, value)
 }
 
+// SetTrailer announces trailer keys, e.g. a checksum of a streamed body.
+// It must be called before the body is written.
+func (c *Context) SetTrailer(keys ...string) {
+	for _, key := range keys {
+		c.Writer.Header().Add("Trailer", http.CanonicalHeaderKey(key))
+	}
+}
+
+// WriteTrailer sets the trailer key to value after the body has been
+// written. Keys not announced with SetTrailer are still sent over HTTP/2
+// and chunked HTTP/1.1 responses.
+func (c *Context) WriteTrailer(key string, value string) {
+	// 带 TrailerPrefix 的键 net/http 总是作为 trailer 发送，中间件包装的 writer 也能透传
+	c.Writer.Header().Set(http.TrailerPrefix+key, value)
+}
+
+// Push asks an HTTP/2 client to fetch target, e.g. critical CSS, before
+// the page referencing it arrives. It does nothing when the connection or
+// the client does not support server push.
+func (c *Context) Push(target string, opts *http.PushOptions) error {
+	if err := c.Writer.Push(target, opts); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
 func (c *Context) String(code int, format string, values ...interface{}) {
 	c.SetHeader("COntent-Type", "text/plain")
 	c.Status(code)
@@ -74,6 +195,9 @@ func (c *Context) JSON(code int, obj interface{}) {
 	c.SetHeader("Content-Type", "application/json")
 	c.Status(code)
 	encoder := json.NewEncoder(c.Writer)
+	if IsDebugging() {
+		encoder.SetIndent("", "  ")
+	}
 	if err := encoder.Encode(obj); err != nil {
 		http.Error(c.Writer, err.Error(), 500)
 	}
@@ -86,9 +210,14 @@ func (c *Context) Data(code int, data []byte) {
 
 // name是模板名称，data用于传递给模板的数据
 func (c *Context) HTML(code int, name string, data interface{}) {
+	tmpl, err := c.engine.templates()
+	if err != nil {
+		c.Fail(500, err.Error())
+		return
+	}
 	c.SetHeader("Content-Type", "text/html")
 	c.Status(code)
-	if err := c.engine.htmlTemplates.ExecuteTemplate(c.Writer, name, data); err != nil {
+	if err := tmpl.ExecuteTemplate(c.Writer, name, data); err != nil {
 		c.Fail(500, err.Error())
 	}
 }
@@ -97,3 +226,37 @@ func (c *Context) Fail(code int, err string) {
 	c.index = len(c.handlers)
 	c.JSON(code, H{"message": err})
 }
+
+// Challenge aborts the request with 401 and a WWW-Authenticate header
+// asking the client to authenticate with scheme in realm.
+func (c *Context) Challenge(scheme string, realm string) {
+	c.SetHeader("WWW-Authenticate", fmt.Sprintf("%s realm=%q", scheme, realm))
+	c.failNegotiated(http.StatusUnauthorized, http.StatusText(http.StatusUnauthorized))
+}
+
+// Unauthorized aborts the request with 401 and a Basic challenge for realm.
+func (c *Context) Unauthorized(realm string) {
+	c.Challenge("Basic", realm)
+}
+
+// Forbidden aborts the request with 403. An empty reason falls back to the
+// standard status text.
+func (c *Context) Forbidden(reason string) {
+	if reason == "" {
+		reason = http.StatusText(http.StatusForbidden)
+	}
+	c.failNegotiated(http.StatusForbidden, reason)
+}
+
+// failNegotiated 和 Fail 一样中断后续处理，浏览器请求返回 HTML，其余返回 JSON
+func (c *Context) failNegotiated(code int, message string) {
+	if !strings.Contains(c.Req.Header.Get("Accept"), "text/html") {
+		c.Fail(code, message)
+		return
+	}
+	c.index = len(c.handlers)
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
+	c.Status(code)
+	fmt.Fprintf(c.Writer, "<html><head><title>%d %s</title></head><body><h1>%d %s</h1><p>%s</p></body></html>",
+		code, http.StatusText(code), code, http.StatusText(code), html.EscapeString(message))
+}
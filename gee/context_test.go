@@ -0,0 +1,40 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChallengeHelpers(t *testing.T) {
+	r := New()
+	private := r.Group("/private")
+	private.Use(func(c *Context) {
+		c.Unauthorized("admin")
+	})
+	private.GET("", func(c *Context) {
+		t.Fatal("handlers after Unauthorized should not run")
+	})
+	r.GET("/forbidden", func(c *Context) {
+		c.Forbidden("")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private", nil))
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") != `Basic realm="admin"` {
+		t.Fatalf("got %d %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	if !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+		t.Fatalf("API clients should get JSON, got %q", w.Header().Get("Content-Type"))
+	}
+
+	// 浏览器请求返回 HTML 页面
+	req := httptest.NewRequest(http.MethodGet, "/forbidden", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), "<h1>403 Forbidden</h1>") {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+}
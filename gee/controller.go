@@ -0,0 +1,90 @@
+package gee
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ControllerRoutes can be implemented by a controller to give explicit
+// patterns for some of its methods, e.g. {"GetUser": "/:id"}.
+type ControllerRoutes interface {
+	Routes() map[string]string
+}
+
+// ControllerMiddlewares can be implemented by a controller to add middleware
+// to all of its routes.
+type ControllerMiddlewares interface {
+	Middlewares() []HandlerFunc
+}
+
+// 方法名前缀和 HTTP 方法的对应关系
+var controllerVerbs = []string{"Get", "Post", "Put", "Patch", "Delete", "Head", "Options"}
+
+// Controller mounts the methods of ctrl with the signature func(*Context) as
+// routes under basePath. The method name gives the verb and the path:
+// GetIndex -> GET basePath, PostCreate -> POST basePath/create,
+// GetByID -> GET basePath/by-id. Dependencies live in ctrl's fields, so
+// ctrl is usually a pointer to a struct built by the application.
+func (group *RouterGroup) Controller(basePath string, ctrl interface{}) *RouterGroup {
+	sub := group.Group(basePath)
+	if m, ok := ctrl.(ControllerMiddlewares); ok {
+		sub.Use(m.Middlewares()...)
+	}
+	var routes map[string]string
+	if r, ok := ctrl.(ControllerRoutes); ok {
+		routes = r.Routes()
+	}
+
+	v := reflect.ValueOf(ctrl)
+	t := v.Type()
+	for i := 0; i < t.NumMethod(); i++ {
+		name := t.Method(i).Name
+		fn, ok := v.Method(i).Interface().(func(*Context))
+		if !ok {
+			continue
+		}
+		verb, rest := splitControllerMethod(name)
+		if verb == "" {
+			continue
+		}
+		pattern, ok := routes[name]
+		if !ok && rest != "" && rest != "Index" {
+			pattern = "/" + kebabCase(rest)
+		}
+		sub.addRoute(strings.ToUpper(verb), pattern, HandlerFunc(fn))
+	}
+	return sub
+}
+
+// splitControllerMethod 把 GetUserList 拆成 Get 和 UserList；
+// Getter 这种前缀后面不是大写字母的不算
+func splitControllerMethod(name string) (verb string, rest string) {
+	for _, v := range controllerVerbs {
+		if !strings.HasPrefix(name, v) {
+			continue
+		}
+		rest = name[len(v):]
+		if rest == "" || unicode.IsUpper([]rune(rest)[0]) {
+			return v, rest
+		}
+	}
+	return "", ""
+}
+
+// kebabCase 把 ShowAll 转成 show-all，连续的大写（如 ByID）视为一个单词
+func kebabCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
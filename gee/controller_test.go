@@ -0,0 +1,51 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type userController struct {
+	users map[string]string
+}
+
+func (u *userController) Routes() map[string]string {
+	return map[string]string{"GetUser": "/:id"}
+}
+
+func (u *userController) GetIndex(c *Context) {
+	c.String(http.StatusOK, "%d users", len(u.users))
+}
+
+func (u *userController) GetUser(c *Context) {
+	c.String(http.StatusOK, u.users[c.Param("id")])
+}
+
+func (u *userController) PostShowAll(c *Context) {
+	c.String(http.StatusOK, "all")
+}
+
+func TestController(t *testing.T) {
+	r := New()
+	r.Controller("/users", &userController{users: map[string]string{"1": "geektutu"}})
+
+	testCases := []struct {
+		method, path, body string
+	}{
+		{"GET", "/users", "1 users"},
+		{"GET", "/users/1", "geektutu"},
+		{"POST", "/users/show-all", "all"},
+	}
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.path, nil))
+		if w.Body.String() != tc.body {
+			t.Errorf("%s %s should yield %q, got %q", tc.method, tc.path, tc.body, w.Body.String())
+		}
+	}
+
+	if kebabCase("GetByID") != "get-by-id" {
+		t.Fatalf("kebabCase(GetByID) = %s", kebabCase("GetByID"))
+	}
+}
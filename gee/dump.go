@@ -0,0 +1,119 @@
+package gee
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DumpConfig configures DumpWithConfig.
+type DumpConfig struct {
+	// MaxBodyBytes 是请求体和响应体各自最多记录的字节数，默认 4KB
+	MaxBodyBytes int
+	// RedactHeaders 中的头部只记录为 [REDACTED]，默认包含认证和 Cookie 相关头部
+	RedactHeaders []string
+}
+
+var defaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+var defaultRedact = func() map[string]bool {
+	m := make(map[string]bool, len(defaultRedactHeaders))
+	for _, h := range defaultRedactHeaders {
+		m[h] = true
+	}
+	return m
+}()
+
+// Dump logs request headers and body plus response status, headers and body
+// at debug level, using the default DumpConfig.
+func Dump() HandlerFunc {
+	return DumpWithConfig(DumpConfig{})
+}
+
+// dumpWriter 把响应原样写出，同时保留前 max 个字节用于记录
+type dumpWriter struct {
+	ResponseWriter
+	body bytes.Buffer
+	max  int
+}
+
+func (w *dumpWriter) Write(b []byte) (int, error) {
+	if rest := w.max - w.body.Len(); rest > 0 {
+		if len(b) < rest {
+			rest = len(b)
+		}
+		w.body.Write(b[:rest])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// DumpWithConfig is Dump with size caps and header redaction. Nothing is
+// captured unless the engine logger has debug level enabled.
+func DumpWithConfig(conf DumpConfig) HandlerFunc {
+	if conf.MaxBodyBytes <= 0 {
+		conf.MaxBodyBytes = 4 << 10
+	}
+	if conf.RedactHeaders == nil {
+		conf.RedactHeaders = defaultRedactHeaders
+	}
+	redact := make(map[string]bool, len(conf.RedactHeaders))
+	for _, h := range conf.RedactHeaders {
+		redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	return func(c *Context) {
+		logger := c.logger()
+		if !logger.Enabled(LevelDebug) {
+			c.Next()
+			return
+		}
+
+		// 只读取请求体的前一部分，剩下的和已读部分拼回去交给处理函数
+		var reqBody []byte
+		if c.Req.Body != nil {
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Req.Body, int64(conf.MaxBodyBytes)))
+			c.Req.Body = readCloser{io.MultiReader(bytes.NewReader(reqBody), c.Req.Body), c.Req.Body}
+		}
+		reqHeaders := dumpHeaders(c.Req.Header, redact)
+
+		w := c.Writer
+		dw := &dumpWriter{ResponseWriter: w, max: conf.MaxBodyBytes}
+		c.Writer = dw
+		c.Next()
+		c.Writer = w
+
+		logger.Log(LevelDebug, "dump",
+			"method", c.Method,
+			"path", c.Req.URL.RequestURI(),
+			"request_headers", reqHeaders,
+			"request_body", string(reqBody),
+			"status", w.Status(),
+			"response_headers", dumpHeaders(w.Header(), redact),
+			"response_body", dw.body.String(),
+		)
+	}
+}
+
+func dumpHeaders(h http.Header, redact map[string]bool) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		v := strings.Join(h[k], ", ")
+		if redact[k] {
+			v = "[REDACTED]"
+		}
+		b.WriteString(k + ": " + v + "\n")
+	}
+	return b.String()
+}
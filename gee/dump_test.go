@@ -0,0 +1,47 @@
+package gee
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	var logs bytes.Buffer
+	r := New()
+	r.SetLogger(NewStdLogger(&logs, LevelDebug))
+	r.Use(DumpWithConfig(DumpConfig{MaxBodyBytes: 4}))
+	r.POST("/echo", func(c *Context) {
+		body, _ := io.ReadAll(c.Req.Body)
+		c.String(http.StatusCreated, "got:%s", body)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello"))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// 截断只影响日志，处理函数和客户端拿到的是完整内容
+	if w.Code != http.StatusCreated || w.Body.String() != "got:hello" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	out := logs.String()
+	for _, want := range []string{"request_body=hell ", "response_body=got:", "status=201", "[REDACTED]"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("dump should contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "s3cret") {
+		t.Fatalf("Authorization should be redacted:\n%s", out)
+	}
+
+	// 没开 debug 级别时不记录
+	logs.Reset()
+	r.SetLogger(NewStdLogger(&logs, LevelInfo))
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader("hello")))
+	if logs.Len() != 0 {
+		t.Fatalf("nothing should be dumped below debug level:\n%s", logs.String())
+	}
+}
@@ -0,0 +1,143 @@
+package gee
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HTTPError is an error carrying the status code and the message shown to
+// the client. Internal is only logged, never sent.
+type HTTPError struct {
+	Code     int
+	Message  string
+	Internal error
+}
+
+// NewError returns an HTTPError; an empty message uses the status text.
+func NewError(code int, message string) *HTTPError {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	return &HTTPError{Code: code, Message: message}
+}
+
+var (
+	ErrBadRequest          = NewError(http.StatusBadRequest, "")
+	ErrUnauthorized        = NewError(http.StatusUnauthorized, "")
+	ErrForbidden           = NewError(http.StatusForbidden, "")
+	ErrNotFound            = NewError(http.StatusNotFound, "")
+	ErrUnprocessableEntity = NewError(http.StatusUnprocessableEntity, "")
+	ErrInternalServerError = NewError(http.StatusInternalServerError, "")
+)
+
+func (e *HTTPError) Error() string {
+	if e.Internal != nil {
+		return fmt.Sprintf("code=%d, message=%s, internal=%v", e.Code, e.Message, e.Internal)
+	}
+	return fmt.Sprintf("code=%d, message=%s", e.Code, e.Message)
+}
+
+// WithInternal returns a copy of e wrapping the underlying cause err.
+func (e *HTTPError) WithInternal(err error) *HTTPError {
+	return &HTTPError{Code: e.Code, Message: e.Message, Internal: err}
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Internal
+}
+
+// Is 只比较状态码，errors.Is(err, gee.ErrNotFound) 对任意 404 的 HTTPError 都成立
+func (e *HTTPError) Is(target error) bool {
+	t, ok := target.(*HTTPError)
+	return ok && t.Code == e.Code
+}
+
+// Error records err on the context so ErrorHandler (or any later middleware)
+// can turn it into a response once the chain finishes. It returns err.
+func (c *Context) Error(err error) error {
+	if err != nil {
+		c.Errors = append(c.Errors, err)
+	}
+	return err
+}
+
+// errorStrings 用于日志和调试输出
+func (c *Context) errorStrings() []string {
+	out := make([]string, 0, len(c.Errors))
+	for _, err := range c.Errors {
+		out = append(out, err.Error())
+	}
+	return out
+}
+
+// ErrorRenderer writes the response for an error collected with c.Error.
+type ErrorRenderer func(c *Context, err error)
+
+// SetErrorHandler sets how ErrorHandler renders errors for routes in this
+// group and its subgroups, e.g. JSON problems under /api and HTML pages
+// under /. Unset groups use their parent's renderer, then the default.
+func (group *RouterGroup) SetErrorHandler(h ErrorRenderer) {
+	group.errorHandler = h
+}
+
+// SetPanicHandler sets the response written by Recovery for routes in this
+// group and its subgroups. Unset groups use their parent's handler, then the
+// handler given to Recovery.
+func (group *RouterGroup) SetPanicHandler(h RecoveryFunc) {
+	group.panicHandler = h
+}
+
+func (c *Context) errorRenderer() ErrorRenderer {
+	for g := c.group; g != nil; g = g.parent {
+		if g.errorHandler != nil {
+			return g.errorHandler
+		}
+	}
+	return defaultErrorRenderer
+}
+
+func (c *Context) panicHandler() RecoveryFunc {
+	for g := c.group; g != nil; g = g.parent {
+		if g.panicHandler != nil {
+			return g.panicHandler
+		}
+	}
+	return nil
+}
+
+// defaultErrorRenderer 的响应格式和 Unauthorized、Forbidden 一致
+func defaultErrorRenderer(c *Context, err error) {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		c.failNegotiated(he.Code, he.Message)
+		return
+	}
+	message := http.StatusText(http.StatusInternalServerError)
+	if IsDebugging() {
+		message = err.Error()
+	}
+	c.failNegotiated(http.StatusInternalServerError, message)
+}
+
+// ErrorHandler runs the chain and, if handlers recorded errors with c.Error
+// without writing a response, renders the last one with the group's
+// ErrorRenderer. By default an *HTTPError decides the status code and public
+// message; any other error becomes a 500 whose details are only exposed in
+// debug mode.
+func ErrorHandler() HandlerFunc {
+	return func(c *Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+		c.logger().Log(LevelError, "request failed",
+			"method", c.Method, "path", c.Path, "errors", strings.Join(c.errorStrings(), "; "))
+		// 处理函数已经写过响应，只记录日志
+		if c.StatusCode != 0 || c.Writer.Written() {
+			return
+		}
+		c.errorRenderer()(c, c.Errors[len(c.Errors)-1])
+	}
+}
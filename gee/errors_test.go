@@ -0,0 +1,114 @@
+package gee
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/users/:id", func(c *Context) {
+		c.Error(NewError(http.StatusNotFound, "user not found").WithInternal(errors.New("sql: no rows")))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	var body H
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusNotFound || body["message"] != "user not found" {
+		t.Fatalf("expected 404 with public message, got %d %q", w.Code, w.Body.String())
+	}
+
+	err := error(NewError(http.StatusNotFound, "user not found"))
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrForbidden) {
+		t.Fatalf("errors.Is should compare HTTPError by status code")
+	}
+}
+
+func TestDefaultModeHidesErrors(t *testing.T) {
+	defer SetMode(Mode())
+	SetMode("")
+	if Mode() != ReleaseMode {
+		t.Fatalf("default mode should be release, got %s", Mode())
+	}
+	r := New()
+	r.Use(ErrorHandler())
+	r.GET("/db", func(c *Context) {
+		c.Error(errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/db", nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "10.0.0.5") {
+		t.Fatalf("internal errors should not reach clients by default, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestContextErrors(t *testing.T) {
+	var logs bytes.Buffer
+	r := New()
+	r.SetLogger(NewStdLogger(&logs, LevelInfo))
+	r.Use(ErrorHandler())
+	r.GET("/many", func(c *Context) {
+		c.Error(nil)
+		c.Error(errors.New("cache miss"))
+		c.Error(ErrForbidden)
+		if len(c.Errors) != 2 {
+			t.Errorf("nil errors should be ignored, got %v", c.Errors)
+		}
+	})
+	r.GET("/written", func(c *Context) {
+		c.Error(errors.New("audit log failed"))
+		c.String(http.StatusOK, "ok")
+	})
+
+	// 渲染最后一个错误，所有错误都记进日志
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/many", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("the last error should decide the response, got %d", w.Code)
+	}
+	if !strings.Contains(logs.String(), "cache miss; code=403") {
+		t.Fatalf("every error should be logged:\n%s", logs.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/written", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "ok" {
+		t.Fatalf("a written response should be kept, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestGroupErrorHandlers(t *testing.T) {
+	r := New()
+	r.Use(Recovery(), ErrorHandler())
+	api := r.Group("/api")
+	api.SetErrorHandler(func(c *Context, err error) {
+		c.JSON(http.StatusTeapot, H{"problem": err.Error()})
+	})
+	api.SetPanicHandler(func(c *Context, err interface{}) {
+		c.String(http.StatusServiceUnavailable, "api down")
+	})
+	v1 := api.Group("/v1")
+	v1.GET("/fail", func(c *Context) { c.Error(errors.New("boom")) })
+	v1.GET("/panic", func(c *Context) { panic("boom") })
+	r.GET("/fail", func(c *Context) { c.Error(ErrNotFound) })
+
+	// 子分组继承父分组的处理函数，其他路由用默认的
+	for path, want := range map[string]int{
+		"/api/v1/fail":  http.StatusTeapot,
+		"/api/v1/panic": http.StatusServiceUnavailable,
+		"/fail":         http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		if w.Code != want {
+			t.Fatalf("%s: status = %d, want %d", path, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package gee
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// etagWriter 先把响应缓存下来，等处理函数结束后再计算 ETag
+type etagWriter struct {
+	ResponseWriter
+	buf    bytes.Buffer
+	status int
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
+
+func (w *etagWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *etagWriter) Size() int {
+	return w.buf.Len()
+}
+
+func (w *etagWriter) Written() bool {
+	return w.status != 0
+}
+
+func (w *etagWriter) WriteHeaderNow() {}
+
+// Flush 什么都不做：完整的 body 缓存下来之后才能计算 ETag
+func (w *etagWriter) Flush() {}
+
+// ETag buffers successful GET/HEAD responses, sets an ETag computed from the
+// body (unless the handler set one) and answers 304 Not Modified when it
+// matches If-None-Match. Use it on the groups whose responses are polled.
+func ETag() HandlerFunc {
+	return func(c *Context) {
+		if c.Method != http.MethodGet && c.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		w := c.Writer
+		ew := &etagWriter{ResponseWriter: w}
+		c.Writer = ew
+		c.Next()
+		c.Writer = w
+
+		if !ew.Written() {
+			return
+		}
+		status := ew.Status()
+		if status == http.StatusOK {
+			etag := w.Header().Get("ETag")
+			if etag == "" {
+				h := fnv.New64a()
+				h.Write(ew.buf.Bytes())
+				etag = fmt.Sprintf("\"%x-%x\"", ew.buf.Len(), h.Sum64())
+				w.Header().Set("ETag", etag)
+			}
+			if etagMatch(c.Req.Header.Get("If-None-Match"), etag) {
+				w.Header().Del("Content-Type")
+				w.Header().Del("Content-Length")
+				c.StatusCode = http.StatusNotModified
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		}
+		w.WriteHeader(status)
+		w.Write(ew.buf.Bytes())
+	}
+}
+
+// NotModified sets the ETag and Last-Modified headers (either may be
+// empty or zero) and checks them against If-None-Match and
+// If-Modified-Since. When the client's copy is current it writes 304 and
+// returns true, and the handler should return without rendering:
+//
+//	if c.NotModified(report.ETag, report.UpdatedAt) {
+//		return
+//	}
+//	c.JSON(http.StatusOK, report)
+func (c *Context) NotModified(etag string, lastModified time.Time) bool {
+	if etag != "" {
+		c.SetHeader("ETag", etag)
+	}
+	if !lastModified.IsZero() {
+		c.SetHeader("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if c.Method != http.MethodGet && c.Method != http.MethodHead {
+		return false
+	}
+	// If-None-Match 优先，有它时忽略 If-Modified-Since
+	if inm := c.Req.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !etagMatch(inm, etag) {
+			return false
+		}
+	} else {
+		ims, err := http.ParseTime(c.Req.Header.Get("If-Modified-Since"))
+		// HTTP 日期只精确到秒
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(ims) {
+			return false
+		}
+	}
+	c.Writer.Header().Del("Content-Type")
+	c.Writer.Header().Del("Content-Length")
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// etagMatch 按弱比较规则判断 If-None-Match 是否包含 etag
+func etagMatch(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(ifNoneMatch, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	r := New()
+	api := r.Group("/api")
+	api.Use(ETag())
+	api.GET("/items", func(c *Context) {
+		c.JSON(http.StatusOK, H{"items": []string{"a", "b"}})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/items", nil))
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" || w.Body.Len() == 0 {
+		t.Fatalf("first request should return 200 with an ETag")
+	}
+
+	req := httptest.NewRequest("GET", "/api/items", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("matching If-None-Match should return 304 without body, got %d", w.Code)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	updated := time.Date(2023, 5, 1, 8, 0, 0, 0, time.UTC)
+	r := New()
+	r.GET("/report", func(c *Context) {
+		if c.NotModified(`"v1"`, updated) {
+			return
+		}
+		c.String(http.StatusOK, "report")
+	})
+
+	cases := []struct {
+		header, value string
+		want          int
+	}{
+		{"", "", http.StatusOK},
+		{"If-None-Match", `"v1"`, http.StatusNotModified},
+		{"If-None-Match", `"v0"`, http.StatusOK},
+		{"If-Modified-Since", updated.Format(http.TimeFormat), http.StatusNotModified},
+		{"If-Modified-Since", updated.Add(-time.Hour).Format(http.TimeFormat), http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/report", nil)
+		if tc.header != "" {
+			req.Header.Set(tc.header, tc.value)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s %s: status %d, want %d", tc.header, tc.value, w.Code, tc.want)
+		}
+		if w.Header().Get("ETag") != `"v1"` {
+			t.Errorf("ETag header = %q", w.Header().Get("ETag"))
+		}
+	}
+}
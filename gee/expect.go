@@ -0,0 +1,42 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// ContinueCheck inspects the headers of a request sent with
+// Expect: 100-continue before its body is uploaded. Returning an error
+// rejects the upload: an *HTTPError picks the status, e.g.
+// ErrUnauthorized or ErrRequestEntityTooLarge, any other error responds
+// 417 Expectation Failed.
+type ContinueCheck func(c *Context) error
+
+// ExpectContinue returns a middleware running check for requests that
+// expect 100 Continue, so large uploads can be refused before the client
+// sends them. net/http only sends 100 Continue once a handler reads the
+// body, so an accepted request continues down the chain unchanged.
+// Bodies declared larger than MaxRequestBodySize are already refused this
+// way.
+func ExpectContinue(check ContinueCheck) HandlerFunc {
+	return func(c *Context) {
+		if !strings.EqualFold(c.Req.Header.Get("Expect"), "100-continue") {
+			c.Next()
+			return
+		}
+		if err := check(c); err != nil {
+			code := http.StatusExpectationFailed
+			var he *HTTPError
+			if errors.As(err, &he) {
+				code = he.Code
+			}
+			c.Error(err)
+			// 客户端可能已经开始发送请求体，关闭连接避免再读它
+			c.SetHeader("Connection", "close")
+			c.failNegotiated(code, err.Error())
+			return
+		}
+		c.Next()
+	}
+}
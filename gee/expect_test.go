@@ -0,0 +1,43 @@
+package gee
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExpectContinue(t *testing.T) {
+	r := New()
+	r.Use(ExpectContinue(func(c *Context) error {
+		if c.Req.Header.Get("Authorization") == "" {
+			return ErrUnauthorized
+		}
+		return nil
+	}))
+	read := false
+	r.PUT("/upload", func(c *Context) {
+		io.ReadAll(c.Req.Body)
+		read = true
+		c.Status(http.StatusCreated)
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/upload", strings.NewReader("data"))
+	req.Header.Set("Expect", "100-continue")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized || read {
+		t.Fatalf("rejected upload: status %d, body read %v", w.Code, read)
+	}
+	if w.Header().Get("Connection") != "close" {
+		t.Fatal("rejected upload should close the connection")
+	}
+
+	req.Header.Set("Authorization", "Bearer token")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated || !read {
+		t.Fatalf("accepted upload: status %d, body read %v", w.Code, read)
+	}
+}
@@ -0,0 +1,63 @@
+package gee
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"time"
+)
+
+// faviconMaxAge 让浏览器长期缓存图标，换图标时浏览器最迟 30 天后更新
+const faviconMaxAge = 30 * 24 * time.Hour
+
+// Favicon returns a middleware serving /favicon.ico from the file at
+// filename, read once into memory. Register it before Logger so browsers'
+// favicon requests don't show up in the logs or reach the router as 404s.
+// It panics if the file cannot be read.
+func Favicon(filename string) HandlerFunc {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		panic("gee: cannot read favicon: " + err.Error())
+	}
+	return favicon(data, path.Ext(filename))
+}
+
+// FaviconFS is Favicon reading name from fsys, e.g. an embed.FS.
+func FaviconFS(fsys fs.FS, name string) HandlerFunc {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		panic("gee: cannot read favicon: " + err.Error())
+	}
+	return favicon(data, path.Ext(name))
+}
+
+func favicon(data []byte, ext string) HandlerFunc {
+	ctype := mime.TypeByExtension(ext)
+	if ctype == "" {
+		ctype = "image/x-icon"
+	}
+	etag := fmt.Sprintf(`"%x"`, sha1.Sum(data))
+	cacheControl := fmt.Sprintf("public, max-age=%d", int64(faviconMaxAge/time.Second))
+	return func(c *Context) {
+		if c.Path != "/favicon.ico" {
+			c.Next()
+			return
+		}
+		// 直接结束处理链，后面的中间件和路由都不再执行
+		c.index = len(c.handlers)
+		if c.Method != http.MethodGet && c.Method != http.MethodHead {
+			c.SetHeader("Allow", "GET, HEAD")
+			c.Status(http.StatusMethodNotAllowed)
+			return
+		}
+		c.SetHeader("Content-Type", ctype)
+		c.SetHeader("Cache-Control", cacheControl)
+		c.SetHeader("ETag", etag)
+		http.ServeContent(c.Writer, c.Req, "favicon.ico", time.Time{}, bytes.NewReader(data))
+	}
+}
@@ -0,0 +1,40 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFavicon(t *testing.T) {
+	r := New()
+	r.Use(FaviconFS(fstest.MapFS{"favicon.ico": {Data: []byte("icon")}}, "favicon.ico"))
+	logged := 0
+	r.Use(func(c *Context) {
+		logged++
+		c.Next()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/favicon.ico", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "icon" || w.Header().Get("Cache-Control") == "" {
+		t.Fatalf("GET: %d %q", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/favicon.ico", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("conditional GET: %d", w.Code)
+	}
+	if logged != 0 {
+		t.Fatalf("later middleware ran %d times", logged)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other", nil))
+	if logged != 1 {
+		t.Fatal("other paths should pass through")
+	}
+}
@@ -2,9 +2,8 @@ package gee
 
 import (
 	"html/template"
-	"log"
+	"mime"
 	"net/http"
-	"path"
 	"strings"
 )
 
@@ -13,7 +12,13 @@ type HandlerFunc func(*Context)
 type RouterGroup struct {
 	prefix      string
 	middlewares []HandlerFunc
+	parent      *RouterGroup
 	engine      *Engine
+	// 为空时使用上级分组的设置，一直到 engine 的默认处理
+	errorHandler ErrorRenderer
+	panicHandler RecoveryFunc
+	// maxBodySize 是请求体大小上限，0 表示沿用上级分组的设置
+	maxBodySize int64
 }
 
 type Engine struct {
@@ -22,11 +27,25 @@ type Engine struct {
 	router        *router
 	groups        []*RouterGroup
 	htmlTemplates *template.Template
-	funcMap       template.FuncMap
+	// debug 模式下每次渲染都按 htmlGlob 重新解析模板
+	htmlGlob string
+	funcMap  template.FuncMap
+	// 只有来自这些代理的请求，ClientIP 才会读取 X-Forwarded-For 等头部
+	trustedProxies *cidrTrie
+	methodOverride bool
+	logger         LeveledLogger
+	health         *Health
+	servers        serverSet
+	h2c            bool
+	http3Server    HTTP3ServerFunc
+	serverConfig   *ServerConfig
+	lifecycle      lifecycle
+	htmlSanitizer  *HTMLSanitizer
+	tasks          tasks
 }
 
 func New() *Engine {
-	engine := &Engine{router: newRouter()}
+	engine := &Engine{router: newRouter(), logger: defaultLogger()}
 	engine.RouterGroup = &RouterGroup{engine: engine}
 	engine.groups = []*RouterGroup{engine.RouterGroup}
 	return engine
@@ -42,49 +61,72 @@ func (group *RouterGroup) Group(prefix string) *RouterGroup {
 	engine := group.engine
 	newGroup := &RouterGroup{
 		prefix: group.prefix + prefix,
+		parent: group,
 		engine: engine,
 	}
 	engine.groups = append(engine.groups, newGroup)
 	return newGroup
 }
 
-func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) {
+func (group *RouterGroup) addRoute(method string, comp string, handler HandlerFunc) *Route {
 	pattern := group.prefix + comp
-	log.Printf("Route %4s - %s", method, pattern)
-	group.engine.router.addRoute(method, pattern, handler)
+	group.engine.logger.Log(LevelDebug, "route registered", "method", method, "pattern", pattern)
+	rt := &Route{
+		method:   method,
+		pattern:  pattern,
+		handlers: group.combineHandlers(handler),
+		group:    group,
+	}
+	group.engine.router.addRoute(method, pattern, rt)
+	return rt
 }
 
-func (group *RouterGroup) GET(pattern string, handler HandlerFunc) {
-	group.addRoute("GET", pattern, handler)
+// combineHandlers 按从根分组到当前分组的顺序收集中间件，再接上处理函数。
+// 处理链在注册路由时就确定了，所以 Use 要在注册路由之前调用
+func (group *RouterGroup) combineHandlers(handler HandlerFunc) []HandlerFunc {
+	var chain []*RouterGroup
+	for g := group; g != nil; g = g.parent {
+		chain = append(chain, g)
+	}
+	var handlers []HandlerFunc
+	for i := len(chain) - 1; i >= 0; i-- {
+		handlers = append(handlers, chain[i].middlewares...)
+	}
+	return append(handlers, handler)
 }
 
-func (group *RouterGroup) POST(pattern string, handler HandlerFunc) {
-	group.addRoute("POST", pattern, handler)
+func (group *RouterGroup) GET(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("GET", pattern, handler)
 }
 
-// 在 Use 方法中，你可能更关心将中间件添加到特定的路由组中
-func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
-	group.middlewares = append(group.middlewares, middlewares...)
+func (group *RouterGroup) POST(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("POST", pattern, handler)
 }
 
-func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
-	absolutePath := path.Join(group.prefix, relativePath)
-	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
-	return func(c *Context) {
-		file := c.Param("filepath")
-		if _, err := fs.Open(file); err != nil {
-			c.Status(http.StatusNotFound)
-			return
-		}
+func (group *RouterGroup) PUT(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("PUT", pattern, handler)
+}
 
-		fileServer.ServeHTTP(c.Writer, c.Req)
-	}
+func (group *RouterGroup) PATCH(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("PATCH", pattern, handler)
+}
+
+func (group *RouterGroup) DELETE(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("DELETE", pattern, handler)
 }
 
-func (group *RouterGroup) Static(relativePath string, root string) {
-	handler := group.createStaticHandler(relativePath, http.Dir(root))
-	urlPattern := path.Join(relativePath, "/*filepath")
-	group.GET(urlPattern, handler)
+func (group *RouterGroup) HEAD(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("HEAD", pattern, handler)
+}
+
+func (group *RouterGroup) OPTIONS(pattern string, handler HandlerFunc) *Route {
+	return group.addRoute("OPTIONS", pattern, handler)
+}
+
+// 在 Use 方法中，你可能更关心将中间件添加到特定的路由组中。
+// 中间件只作用于之后在该分组及其子分组上注册的路由
+func (group *RouterGroup) Use(middlewares ...HandlerFunc) {
+	group.middlewares = append(group.middlewares, middlewares...)
 }
 
 func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
@@ -92,11 +134,114 @@ func (engine *Engine) SetFuncMap(funcMap template.FuncMap) {
 }
 
 func (engine *Engine) LoadHTMLGlob(pattern string) {
-	engine.htmlTemplates = template.Must(template.New("").Funcs(engine.funcMap).ParseGlob(pattern))
+	engine.htmlGlob = pattern
+	engine.htmlTemplates = template.Must(engine.parseHTMLGlob())
+}
+
+func (engine *Engine) parseHTMLGlob() (*template.Template, error) {
+	return template.New("").Funcs(engine.funcMap).ParseGlob(engine.htmlGlob)
+}
+
+// templates 返回用于渲染的模板，debug 模式下从磁盘重新加载，修改模板不用重启
+func (engine *Engine) templates() (*template.Template, error) {
+	if IsDebugging() && engine.htmlGlob != "" {
+		return engine.parseHTMLGlob()
+	}
+	return engine.htmlTemplates, nil
 }
 
+// SetTrustedProxies sets the proxies (IPs or CIDRs) whose X-Forwarded-For and
+// X-Real-IP headers are trusted by Context.ClientIP.
+func (engine *Engine) SetTrustedProxies(proxies ...string) error {
+	trie, err := newCIDRTrie(proxies)
+	if err != nil {
+		return err
+	}
+	engine.trustedProxies = trie
+	return nil
+}
+
+// SetMethodOverride lets POST requests choose the method used for routing via
+// the X-HTTP-Method-Override header or a _method form field, so HTML forms
+// can reach PUT, PATCH and DELETE routes. The form field is only read from
+// application/x-www-form-urlencoded bodies, within MaxRequestBodySize, and
+// never from requests waiting for 100 Continue or from the query string.
+func (engine *Engine) SetMethodOverride(enabled bool) {
+	engine.methodOverride = enabled
+}
+
+// overrideMethods 是 POST 可以改写成的方法
+var overrideMethods = []string{http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// overrideMethod 必须在路由匹配之前执行，只允许 POST 改写为 PUT/PATCH/DELETE。
+// 读表单时请求体超过限制返回 false
+func (r *router) overrideMethod(c *Context) bool {
+	req := c.Req
+	if req.Method != http.MethodPost {
+		return true
+	}
+	method := req.Header.Get("X-HTTP-Method-Override")
+	if method == "" {
+		var ok bool
+		if method, ok = r.formMethod(c); !ok {
+			return false
+		}
+	}
+	switch method = strings.ToUpper(method); method {
+	case http.MethodPut, http.MethodPatch, http.MethodDelete:
+		req.Method = method
+		c.Method = method
+	}
+	return true
+}
+
+// formMethod 读取表单里的 _method。请求体按可能匹配到的路由中最严格的限制读取，
+// 没有可以改写过去的路由时不读请求体
+func (r *router) formMethod(c *Context) (method string, ok bool) {
+	req := c.Req
+	if req.Body == nil || req.Body == http.NoBody || strings.EqualFold(req.Header.Get("Expect"), "100-continue") {
+		return "", true
+	}
+	if ct, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); ct != "application/x-www-form-urlencoded" {
+		return "", true
+	}
+	var limit int64
+	matched := false
+	for _, m := range append([]string{http.MethodPost}, overrideMethods...) {
+		n, _ := r.getRoute(m, c.Path)
+		if n == nil {
+			continue
+		}
+		if m != http.MethodPost {
+			matched = true
+		}
+		if l := r.routes[m+"-"+n.pattern].group.bodyLimit(); l > 0 && (limit == 0 || l < limit) {
+			limit = l
+		}
+	}
+	if !matched {
+		return "", true
+	}
+	if limit > 0 {
+		if req.ContentLength > limit {
+			return "", false
+		}
+		req.Body = http.MaxBytesReader(c.Writer, req.Body, limit)
+	}
+	if err := req.ParseForm(); IsBodyTooLarge(err) {
+		return "", false
+	}
+	return req.PostForm.Get("_method"), true
+}
+
+// Run serves on addr until Shutdown is called.
 func (engine *Engine) Run(addr string) (err error) {
-	return http.ListenAndServe(addr, engine)
+	if err := engine.start(); err != nil {
+		return err
+	}
+	srv := engine.newServer(addr)
+	srv.Handler = engine.cleartextHandler()
+	return serveErr(srv.ListenAndServe())
 }
 
 // http.ListenAndServe函数的第二个参数需要实现 http.Handler 接口。在你的代码中，engine 类型实现了 ServeHTTP 方法，因此它隐式地实现了 http.Handler 接口。
@@ -105,14 +250,37 @@ func (engine *Engine) Run(addr string) (err error) {
 
 // 在 ServeHTTP 方法中，你可能想要按照路由组的顺序将中间件组合起来，确保它们按照路由组的顺序执行。
 func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	var middlewares []HandlerFunc
-	for _, group := range engine.groups {
-		if strings.HasPrefix(req.URL.Path, group.prefix) {
-			middlewares = append(middlewares, group.middlewares...)
-		}
-	}
 	c := newContext(w, req)
-	c.handlers = middlewares
 	c.engine = engine
+	defer c.runFinish()
+	engine.router.handle(c)
+}
+
+// maxHandleContext 限制一个请求内部转发的次数，防止重写规则互相转发形成死循环
+const maxHandleContext = 10
+
+// HandleContext routes c again using c.Req's current method and path, so
+// a handler can forward a request internally, e.g. from a legacy URL,
+// without a client-visible redirect:
+//
+//	c.Req.URL.Path = "/v2/users"
+//	engine.HandleContext(c)
+//
+// Values set with c.Set are kept. The calling chain is aborted afterwards.
+// More than 10 forwards for one request respond 508 Loop Detected.
+func (engine *Engine) HandleContext(c *Context) {
+	c.forwards++
+	if c.forwards > maxHandleContext {
+		c.Fail(http.StatusLoopDetected, "too many internal forwards")
+		return
+	}
+	c.Path = c.Req.URL.Path
+	c.Method = c.Req.Method
+	c.Params = nil
+	c.fullPath = ""
+	c.handlers = nil
+	c.index = -1
 	engine.router.handle(c)
+	// 转发后的处理链已经写完响应，原来的处理链不再继续
+	c.index = len(c.handlers)
 }
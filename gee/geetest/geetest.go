@@ -0,0 +1,172 @@
+// Package geetest helps unit-test gee handlers, middleware and engines:
+//
+//	resp := geetest.NewRequest(http.MethodGet, "/users/1").
+//		Param("id", "1").
+//		Run(getUser)
+//	resp.AssertStatus(t, http.StatusOK)
+//	resp.AssertJSON(t, gee.H{"id": "1"})
+package geetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"gee"
+)
+
+// Request builds the request a test sends. Its methods return the Request
+// so calls can be chained.
+type Request struct {
+	method string
+	target string
+	header http.Header
+	body   []byte
+	params map[string]string
+}
+
+// NewRequest starts a request for method and target, e.g. "/users?page=2".
+func NewRequest(method string, target string) *Request {
+	return &Request{
+		method: method,
+		target: target,
+		header: make(http.Header),
+		params: make(map[string]string),
+	}
+}
+
+// Header sets a request header.
+func (r *Request) Header(key string, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// Param sets a route parameter. It is only used by Run, since Serve gets
+// the parameters from the engine's router.
+func (r *Request) Param(key string, value string) *Request {
+	r.params[key] = value
+	return r
+}
+
+// Body sets the raw request body.
+func (r *Request) Body(body string) *Request {
+	r.body = []byte(body)
+	return r
+}
+
+// JSON sets the body to v encoded as JSON, and the Content-Type header.
+// It panics if v cannot be encoded.
+func (r *Request) JSON(v interface{}) *Request {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic("geetest: " + err.Error())
+	}
+	r.body = body
+	return r.Header("Content-Type", "application/json")
+}
+
+// Form sets the body to the url-encoded form values, and the Content-Type
+// header.
+func (r *Request) Form(values url.Values) *Request {
+	r.body = []byte(values.Encode())
+	return r.Header("Content-Type", "application/x-www-form-urlencoded")
+}
+
+// Build returns the *http.Request.
+func (r *Request) Build() *http.Request {
+	var body io.Reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+	req := httptest.NewRequest(r.method, r.target, body)
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	return req
+}
+
+// Context returns a gee Context for the request, with the route
+// parameters set, and the recorder it writes to.
+func (r *Request) Context() (*gee.Context, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gee.CreateTestContext(w, r.Build())
+	c.Params = make(map[string]string, len(r.params))
+	for k, v := range r.params {
+		c.Params[k] = v
+	}
+	return c, w
+}
+
+// Run runs handlers as a chain on a fresh Context, e.g. a middleware
+// followed by a handler checking what it set.
+func (r *Request) Run(handlers ...gee.HandlerFunc) *Response {
+	c, w := r.Context()
+	gee.RunTestContext(c, handlers...)
+	return &Response{ResponseRecorder: w}
+}
+
+// Serve sends the request through engine, including routing and all
+// middleware.
+func (r *Request) Serve(engine *gee.Engine) *Response {
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r.Build())
+	return &Response{ResponseRecorder: w}
+}
+
+// Response is the recorded response with assertion helpers.
+type Response struct {
+	*httptest.ResponseRecorder
+}
+
+// DecodeJSON decodes the response body into v.
+func (r *Response) DecodeJSON(v interface{}) error {
+	return json.Unmarshal(r.Body.Bytes(), v)
+}
+
+// AssertStatus fails the test unless the status code is code.
+func (r *Response) AssertStatus(t testing.TB, code int) {
+	t.Helper()
+	if r.Code != code {
+		t.Fatalf("status = %d, want %d; body: %s", r.Code, code, r.Body.String())
+	}
+}
+
+// AssertHeader fails the test unless header key has value.
+func (r *Response) AssertHeader(t testing.TB, key string, value string) {
+	t.Helper()
+	if got := r.Header().Get(key); got != value {
+		t.Fatalf("header %s = %q, want %q", key, got, value)
+	}
+}
+
+// AssertBody fails the test unless the body is exactly body.
+func (r *Response) AssertBody(t testing.TB, body string) {
+	t.Helper()
+	if got := r.Body.String(); got != body {
+		t.Fatalf("body = %q, want %q", got, body)
+	}
+}
+
+// AssertJSON fails the test unless the body is JSON equal to want. Both are
+// compared after decoding, so formatting and key order do not matter.
+func (r *Response) AssertJSON(t testing.TB, want interface{}) {
+	t.Helper()
+	var got interface{}
+	if err := r.DecodeJSON(&got); err != nil {
+		t.Fatalf("body is not JSON: %v; body: %s", err, r.Body.String())
+	}
+	raw, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("cannot encode want: %v", err)
+	}
+	var expected interface{}
+	json.Unmarshal(raw, &expected)
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("body = %s, want %s", r.Body.String(), raw)
+	}
+}
@@ -0,0 +1,46 @@
+package geetest
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"gee"
+)
+
+func TestRun(t *testing.T) {
+	auth := func(c *gee.Context) {
+		if c.Req.Header.Get("Authorization") == "" {
+			c.Fail(http.StatusUnauthorized, "missing token")
+			return
+		}
+		c.Set("user", "geektutu")
+		c.Next()
+	}
+	handler := func(c *gee.Context) {
+		c.JSON(http.StatusOK, gee.H{"id": c.Param("id"), "user": c.GetString("user")})
+	}
+
+	resp := NewRequest(http.MethodGet, "/users/1").
+		Header("Authorization", "Bearer token").
+		Param("id", "1").
+		Run(auth, handler)
+	resp.AssertStatus(t, http.StatusOK)
+	resp.AssertJSON(t, gee.H{"id": "1", "user": "geektutu"})
+
+	NewRequest(http.MethodGet, "/users/1").Run(auth, handler).
+		AssertStatus(t, http.StatusUnauthorized)
+}
+
+func TestServe(t *testing.T) {
+	r := gee.New()
+	r.POST("/login", func(c *gee.Context) {
+		c.String(http.StatusOK, "hello %s", c.PostForm("name"))
+	})
+
+	resp := NewRequest(http.MethodPost, "/login").
+		Form(url.Values{"name": {"gee"}}).
+		Serve(r)
+	resp.AssertStatus(t, http.StatusOK)
+	resp.AssertBody(t, "hello gee")
+}
@@ -1,3 +1,11 @@
 module gee
 
 go 1.18
+
+require (
+	golang.org/x/crypto v0.22.0
+	golang.org/x/net v0.23.0
+	google.golang.org/protobuf v1.33.0
+)
+
+require golang.org/x/text v0.14.0 // indirect
@@ -0,0 +1,23 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package gee
+
+import (
+	"errors"
+	"runtime"
+	"time"
+)
+
+// EnvGeeInheritFD tells a restarted process which file descriptor holds
+// the listener inherited from its parent.
+const EnvGeeInheritFD = "GEE_INHERIT_FD"
+
+// EnvGeeReadyFD tells a restarted process which file descriptor to write
+// to once it is serving, so the parent knows it can stop.
+const EnvGeeReadyFD = "GEE_READY_FD"
+
+// RunGraceful needs to pass the listening socket to a child process, which
+// is only supported on unix systems.
+func (engine *Engine) RunGraceful(addr string, timeout time.Duration) error {
+	return errors.New("gee: graceful restart is not supported on " + runtime.GOOS)
+}
@@ -0,0 +1,166 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package gee
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// EnvGeeInheritFD tells a restarted process which file descriptor holds
+// the listener inherited from its parent.
+const EnvGeeInheritFD = "GEE_INHERIT_FD"
+
+// EnvGeeReadyFD tells a restarted process which file descriptor to write
+// to once it is serving, so the parent knows it can stop.
+const EnvGeeReadyFD = "GEE_READY_FD"
+
+// readyTimeout 是父进程等新进程就绪的最长时间
+const readyTimeout = time.Minute
+
+// RunGraceful runs the engine on addr like RunWithSignals, and on SIGUSR2
+// starts a new copy of the binary that inherits the listening socket. Once
+// the new process reports it is serving, the old one drains in-flight
+// requests for up to timeout and exits; if the new process fails to start
+// or isn't ready within a minute, the old one keeps serving. Connections
+// arriving during the handover wait in the shared socket's backlog, so a
+// deploy only needs to replace the binary and send SIGUSR2.
+func (engine *Engine) RunGraceful(addr string, timeout time.Duration) error {
+	// 先订阅信号再启动，OnStart 钩子执行期间到达的信号也能收到
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR2)
+	defer signal.Stop(quit)
+
+	listener, err := inheritOrListen(addr)
+	if err != nil {
+		return err
+	}
+	// OnStart 钩子执行成功才算就绪，RunListener 里不会再执行一遍
+	if err := engine.start(); err != nil {
+		listener.Close()
+		return err
+	}
+	notifyReady()
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- engine.RunListener(listener)
+	}()
+
+	for {
+		select {
+		case err := <-errChan:
+			return err
+		case sig := <-quit:
+			if sig == syscall.SIGUSR2 {
+				pid, err := startChild(listener)
+				if err != nil {
+					// 新进程没起来就继续服务，不能把监听关掉
+					engine.logger.Log(LevelError, "graceful restart failed", "error", err.Error())
+					continue
+				}
+				engine.logger.Log(LevelInfo, "graceful restart", "pid", pid)
+			} else {
+				engine.logger.Log(LevelInfo, "shutting down", "signal", sig.String())
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			if err := engine.Shutdown(ctx); err != nil {
+				return err
+			}
+			return <-errChan
+		}
+	}
+}
+
+// inheritOrListen 由父进程启动时复用继承的监听，否则新建一个
+func inheritOrListen(addr string) (net.Listener, error) {
+	fd := os.Getenv(EnvGeeInheritFD)
+	if fd == "" {
+		return net.Listen("tcp", addr)
+	}
+	os.Unsetenv(EnvGeeInheritFD)
+	if fd != "3" {
+		return nil, errors.New("gee: unexpected inherited fd " + fd)
+	}
+	f := os.NewFile(3, "listener")
+	defer f.Close()
+	// FileListener 复制了 fd，原来的文件可以关闭
+	return net.FileListener(f)
+}
+
+// notifyReady 告诉父进程新进程已经开始服务，父进程收到后才停止接受连接
+func notifyReady() {
+	fd := os.Getenv(EnvGeeReadyFD)
+	if fd == "" {
+		return
+	}
+	os.Unsetenv(EnvGeeReadyFD)
+	if fd != "4" {
+		return
+	}
+	f := os.NewFile(4, "ready")
+	f.Write([]byte{1})
+	f.Close()
+}
+
+// startChild 以相同的参数启动新进程，监听作为 fd 3 传过去，并等它通过 fd 4 报告就绪
+func startChild(listener net.Listener) (int, error) {
+	fl, ok := listener.(interface{ File() (*os.File, error) })
+	if !ok {
+		return 0, errors.New("gee: listener cannot be inherited")
+	}
+	f, err := fl.File()
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	path, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+	env := make([]string, 0, len(os.Environ())+2)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, EnvGeeInheritFD+"=") && !strings.HasPrefix(kv, EnvGeeReadyFD+"=") {
+			env = append(env, kv)
+		}
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return 0, err
+	}
+	defer r.Close()
+	cmd := exec.Command(path, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(env, EnvGeeInheritFD+"=3", EnvGeeReadyFD+"=4")
+	cmd.ExtraFiles = []*os.File{f, w}
+	err = cmd.Start()
+	// 父进程要关掉自己的写端，子进程退出时才能读到 EOF
+	w.Close()
+	if err != nil {
+		return 0, err
+	}
+	if err := waitReady(r, readyTimeout); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return 0, err
+	}
+	return cmd.Process.Pid, nil
+}
+
+// waitReady 等新进程写入就绪标记；新进程退出或超时都算失败
+func waitReady(r *os.File, timeout time.Duration) error {
+	r.SetReadDeadline(time.Now().Add(timeout))
+	if _, err := r.Read(make([]byte, 1)); err != nil {
+		return fmt.Errorf("gee: new process not ready: %w", err)
+	}
+	return nil
+}
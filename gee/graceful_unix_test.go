@@ -0,0 +1,86 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package gee
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+// TestGracefulChild 是 TestGracefulReady 启动的新进程，单独运行时直接跳过
+func TestGracefulChild(t *testing.T) {
+	if os.Getenv("GEE_TEST_GRACEFUL_CHILD") == "" {
+		t.Skip("only run as the restarted process")
+	}
+	r := New()
+	r.GET("/who", func(c *Context) {
+		c.String(http.StatusOK, "child")
+	})
+	r.RunGraceful("", time.Second)
+}
+
+func TestGracefulReady(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	f, err := l.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestGracefulChild$")
+	cmd.Env = append(os.Environ(), "GEE_TEST_GRACEFUL_CHILD=1", EnvGeeInheritFD+"=3", EnvGeeReadyFD+"=4")
+	cmd.ExtraFiles = []*os.File{f, w}
+	err = cmd.Start()
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	if err := waitReady(r, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	// 就绪之后新进程已经在继承的监听上服务
+	resp, err := http.Get("http://" + l.Addr().String() + "/who")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "child" {
+		t.Fatalf("body = %q", body)
+	}
+}
+
+func TestWaitReadyFails(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if err := waitReady(r, 10*time.Millisecond); err == nil {
+		t.Fatal("waitReady should time out")
+	}
+	// 新进程没有报告就绪就退出
+	w.Close()
+	if err := waitReady(r, time.Second); err == nil {
+		t.Fatal("waitReady should fail when the new process exits")
+	}
+}
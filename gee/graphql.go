@@ -0,0 +1,73 @@
+package gee
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// GraphQLConfig configures RouterGroup.GraphQL.
+type GraphQLConfig struct {
+	// Playground 为 true 时浏览器 GET 访问返回 GraphiQL 页面
+	Playground bool
+}
+
+// contextKey 是 gee.Context 在 request context 中的键
+type contextKey struct{}
+
+// FromContext returns the gee Context of the request ctx belongs to, so
+// code that only gets a context.Context, such as GraphQL resolvers, can
+// read values set by middleware:
+//
+//	c, _ := gee.FromContext(ctx)
+//	user, _ := c.Subject()
+func FromContext(ctx context.Context) (*Context, bool) {
+	c, ok := ctx.Value(contextKey{}).(*Context)
+	return c, ok
+}
+
+// GraphQL mounts a GraphQL server, e.g. one built with graphql-go or
+// gqlgen, at path for GET and POST, behind the group's middleware. The
+// request context handed to handler carries the gee Context, see
+// FromContext.
+func (group *RouterGroup) GraphQL(path string, handler http.Handler, conf GraphQLConfig) {
+	serve := func(c *Context) {
+		c.Req = c.Req.WithContext(context.WithValue(c.Req.Context(), contextKey{}, c))
+		handler.ServeHTTP(c.Writer, c.Req)
+	}
+	// playground 里用注册的路径，不用请求里的路径：它会被原样放进 JS 字符串
+	page := strings.Replace(graphiQLPage, "{{endpoint}}", template.JSEscapeString(group.prefix+path), 1)
+	group.POST(path, serve)
+	group.GET(path, func(c *Context) {
+		// 带 query 参数的 GET 是查询请求，只有浏览器直接打开时才返回 playground
+		if conf.Playground && c.Query("query") == "" && strings.Contains(c.Req.Header.Get("Accept"), "text/html") {
+			c.SetHeader("Content-Type", "text/html; charset=utf-8")
+			c.Status(http.StatusOK)
+			c.Writer.Write([]byte(page))
+			return
+		}
+		serve(c)
+	})
+}
+
+const graphiQLPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GraphiQL</title>
+<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+<script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+<script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+<script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+</head>
+<body style="margin: 0">
+<div id="graphiql" style="height: 100vh"></div>
+<script>
+ReactDOM.createRoot(document.getElementById("graphiql")).render(
+  React.createElement(GraphiQL, {fetcher: GraphiQL.createFetcher({url: "{{endpoint}}"})})
+);
+</script>
+</body>
+</html>
+`
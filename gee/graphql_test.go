@@ -0,0 +1,54 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGraphQL(t *testing.T) {
+	r := New()
+	r.Use(func(c *Context) {
+		c.Set(SubjectKey, Subject{ID: "42"})
+		c.Next()
+	})
+	schema := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		c, ok := FromContext(req.Context())
+		if !ok {
+			http.Error(w, "no gee context", http.StatusInternalServerError)
+			return
+		}
+		subject, _ := c.Subject()
+		w.Write([]byte(`{"data":{"me":"` + subject.ID + `"}}`))
+	})
+	r.GraphQL("/graphql", schema, GraphQLConfig{Playground: true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{me}"}`)))
+	if w.Body.String() != `{"data":{"me":"42"}}` {
+		t.Fatalf("POST body = %q", w.Body.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	req.Header.Set("Accept", "text/html")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "GraphiQL") || !strings.Contains(w.Body.String(), `{url: "/graphql"}`) {
+		t.Fatal("browser GET should get the playground")
+	}
+}
+
+func TestGraphQLPlaygroundEscapesPath(t *testing.T) {
+	r := New()
+	api := r.Group("/api")
+	api.GraphQL("/graphql\"</script>", http.NotFoundHandler(), GraphQLConfig{Playground: true})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/graphql%22%3C/script%3E", nil)
+	req.Header.Set("Accept", "text/html")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "GraphiQL") || strings.Contains(w.Body.String(), `"</script>`) {
+		t.Fatalf("the endpoint should be escaped inside the script, got %q", w.Body.String())
+	}
+}
@@ -0,0 +1,23 @@
+package gee
+
+import (
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// SetH2C makes Run serve HTTP/2 without TLS (h2c) next to HTTP/1.1, for
+// gRPC-gateway style clients and internal load balancers. TLS listeners
+// negotiate HTTP/2 on their own and are not affected.
+func (engine *Engine) SetH2C(enabled bool) {
+	engine.h2c = enabled
+}
+
+// cleartextHandler 是明文监听使用的 Handler，开启 h2c 时包一层 h2c.NewHandler
+func (engine *Engine) cleartextHandler() http.Handler {
+	if engine.h2c {
+		return h2c.NewHandler(engine, &http2.Server{})
+	}
+	return engine
+}
@@ -0,0 +1,50 @@
+package gee
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"golang.org/x/net/http2"
+)
+
+func TestH2C(t *testing.T) {
+	r := New()
+	r.SetH2C(true)
+	r.GET("/proto", func(c *Context) {
+		c.String(http.StatusOK, "%d", c.Req.ProtoMajor)
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunListener(l) }()
+
+	// prior knowledge：客户端不升级，直接在明文连接上说 HTTP/2
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}}
+	resp, err := client.Get("http://" + l.Addr().String() + "/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.ProtoMajor != 2 || string(body) != "2" {
+		t.Fatalf("request should be served over HTTP/2, got %s, server saw HTTP/%s", resp.Proto, body)
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,109 @@
+package gee
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthCheck reports a problem by returning an error. It should respect
+// ctx, which is canceled after Health.Timeout; a check still running then
+// is reported as failed.
+type HealthCheck func(ctx context.Context) error
+
+// Health aggregates liveness and readiness checks served at /healthz and
+// /readyz for Kubernetes probes. The endpoints are public, so failed checks
+// only include their error message in DebugMode.
+type Health struct {
+	// Timeout 是一次探测中所有检查共用的超时时间，默认 5 秒
+	Timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []namedCheck
+	readiness []namedCheck
+}
+
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// Health returns the engine's health registry, mounting /healthz and
+// /readyz on first use.
+func (engine *Engine) Health() *Health {
+	if engine.health == nil {
+		engine.health = &Health{Timeout: 5 * time.Second}
+		engine.GET("/healthz", engine.health.handler(func(h *Health) []namedCheck { return h.liveness }))
+		engine.GET("/readyz", engine.health.handler(func(h *Health) []namedCheck { return h.readiness }))
+	}
+	return engine.health
+}
+
+// Liveness registers a check that makes /healthz fail, i.e. the process
+// should be restarted.
+func (h *Health) Liveness(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, namedCheck{name, check})
+}
+
+// Readiness registers a check that makes /readyz fail, i.e. the instance
+// should not receive traffic right now.
+func (h *Health) Readiness(name string, check HealthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, namedCheck{name, check})
+}
+
+func (h *Health) handler(list func(*Health) []namedCheck) HandlerFunc {
+	return func(c *Context) {
+		h.mu.RLock()
+		checks := list(h)
+		h.mu.RUnlock()
+
+		ctx, cancel := context.WithTimeout(c.Req.Context(), h.Timeout)
+		defer cancel()
+
+		// 各项检查并发执行，总耗时取决于最慢的那一项
+		results := make(H, len(checks))
+		healthy := true
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for _, nc := range checks {
+			wg.Add(1)
+			go func(nc namedCheck) {
+				defer wg.Done()
+				start := time.Now()
+				done := make(chan error, 1)
+				go func() { done <- nc.check(ctx) }()
+				var err error
+				select {
+				case err = <-done:
+				case <-ctx.Done():
+					// 不理会 ctx 的检查不能拖住整个探测
+					err = ctx.Err()
+				}
+				result := H{"status": "ok", "latency": time.Since(start).String()}
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					healthy = false
+					result["status"] = "fail"
+					// 错误信息可能带有内部地址等细节，只在调试模式输出
+					if IsDebugging() {
+						result["error"] = err.Error()
+					}
+				}
+				results[nc.name] = result
+			}(nc)
+		}
+		wg.Wait()
+
+		if !healthy {
+			c.JSON(http.StatusServiceUnavailable, H{"status": "fail", "checks": results})
+			return
+		}
+		c.JSON(http.StatusOK, H{"status": "ok", "checks": results})
+	}
+}
@@ -0,0 +1,66 @@
+package gee
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHealthCheckTimeout(t *testing.T) {
+	r := New()
+	h := r.Health()
+	h.Timeout = 20 * time.Millisecond
+	block := make(chan struct{})
+	defer close(block)
+	h.Readiness("stuck", func(ctx context.Context) error {
+		// 不理会 ctx 的检查
+		<-block
+		return nil
+	})
+	h.Readiness("db", func(ctx context.Context) error { return nil })
+
+	done := make(chan *httptest.ResponseRecorder, 1)
+	go func() {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		done <- w
+	}()
+	select {
+	case w := <-done:
+		if w.Code != http.StatusServiceUnavailable {
+			t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a check ignoring ctx should not hang the probe")
+	}
+}
+
+func TestHealthCheckErrorDetails(t *testing.T) {
+	defer SetMode(Mode())
+	r := New()
+	r.Health().Liveness("db", func(ctx context.Context) error {
+		return errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	})
+
+	for _, tt := range []struct {
+		mode   string
+		detail bool
+	}{
+		{ReleaseMode, false},
+		{DebugMode, true},
+	} {
+		SetMode(tt.mode)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"db"`) {
+			t.Fatalf("%s mode: got %d %s", tt.mode, w.Code, w.Body.String())
+		}
+		if got := strings.Contains(w.Body.String(), "10.0.0.5"); got != tt.detail {
+			t.Fatalf("%s mode: error detail shown = %v, body = %s", tt.mode, got, w.Body.String())
+		}
+	}
+}
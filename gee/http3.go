@@ -0,0 +1,59 @@
+package gee
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// HTTP3Server is the QUIC listener used by RunHTTP3. *http3.Server from
+// github.com/quic-go/quic-go satisfies it; gee does not import a QUIC
+// implementation itself so it keeps building with Go 1.18.
+type HTTP3Server interface {
+	ListenAndServeTLS(certFile string, keyFile string) error
+	Close() error
+}
+
+// HTTP3ServerFunc creates the HTTP3Server listening on addr, e.g.
+//
+//	engine.SetHTTP3Server(func(addr string, h http.Handler) gee.HTTP3Server {
+//		return &http3.Server{Addr: addr, Handler: h}
+//	})
+type HTTP3ServerFunc func(addr string, handler http.Handler) HTTP3Server
+
+// SetHTTP3Server sets how RunHTTP3 creates its QUIC listener.
+func (engine *Engine) SetHTTP3Server(fn HTTP3ServerFunc) {
+	engine.http3Server = fn
+}
+
+// RunHTTP3 serves HTTP/3 over QUIC (UDP) and HTTPS over TCP on addr. The
+// TCP responses carry an Alt-Svc header so clients switch to HTTP/3.
+func (engine *Engine) RunHTTP3(addr string, certFile string, keyFile string) error {
+	if engine.http3Server == nil {
+		return errors.New("gee: RunHTTP3 needs a QUIC implementation, see SetHTTP3Server")
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if err := engine.start(); err != nil {
+		return err
+	}
+	quicSrv := engine.http3Server(addr, engine)
+	engine.servers.addCloser(quicSrv)
+
+	// TCP 上的响应通过 Alt-Svc 告诉客户端同一端口还提供 h3
+	altSvc := fmt.Sprintf(`h3=":%s"; ma=2592000`, port)
+	srv := engine.newServer(addr)
+	srv.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Alt-Svc", altSvc)
+		engine.ServeHTTP(w, req)
+	})
+
+	return engine.serveGroup(func() error {
+		return quicSrv.ListenAndServeTLS(certFile, keyFile)
+	}, func() error {
+		return srv.ListenAndServeTLS(certFile, keyFile)
+	})
+}
@@ -0,0 +1,209 @@
+package gee
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// LocaleKey is the context key the I18n middleware stores the locale under.
+	LocaleKey = "gee/locale"
+	i18nKey   = "gee/i18n"
+)
+
+// I18n holds the message catalogs of an application. Catalogs are flat JSON
+// objects mapping message keys to fmt format strings, one file per locale
+// named after it, e.g. en.json, zh-CN.json.
+type I18n struct {
+	// QueryParam 和 CookieName 是检测语言时依次查看的查询参数和 Cookie，
+	// 都没有时再看 Accept-Language
+	QueryParam string
+	CookieName string
+
+	defaultLocale string
+	mu            sync.RWMutex
+	catalogs      map[string]map[string]string
+}
+
+// NewI18n creates an I18n falling back to defaultLocale.
+func NewI18n(defaultLocale string) *I18n {
+	return &I18n{
+		QueryParam:    "lang",
+		CookieName:    "lang",
+		defaultLocale: defaultLocale,
+		catalogs:      make(map[string]map[string]string),
+	}
+}
+
+// AddMessages merges messages into the catalog of locale.
+func (i *I18n) AddMessages(locale string, messages map[string]string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	catalog, ok := i.catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		i.catalogs[locale] = catalog
+	}
+	for k, v := range messages {
+		catalog[k] = v
+	}
+}
+
+// LoadFS loads every catalog file matching pattern (e.g. "locales/*.json")
+// from fsys, which may be an embed.FS.
+func (i *I18n) LoadFS(fsys fs.FS, pattern string) error {
+	files, err := fs.Glob(fsys, pattern)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("i18n: parse %s: %v", file, err)
+		}
+		base := path.Base(file)
+		i.AddMessages(strings.TrimSuffix(base, path.Ext(base)), messages)
+	}
+	return nil
+}
+
+// LoadDir loads the *.json catalogs in dir.
+func (i *I18n) LoadDir(dir string) error {
+	return i.LoadFS(os.DirFS(dir), "*.json")
+}
+
+// Translate returns the message key of locale formatted with args. It falls
+// back to the base language (zh-CN -> zh), then the default locale, then
+// the key itself.
+func (i *I18n) Translate(locale string, key string, args ...interface{}) string {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, l := range []string{locale, baseLanguage(locale), i.defaultLocale} {
+		if msg, ok := i.catalogs[l][key]; ok {
+			if len(args) == 0 {
+				return msg
+			}
+			return fmt.Sprintf(msg, args...)
+		}
+	}
+	return key
+}
+
+func (i *I18n) supported(locale string) (string, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	for _, l := range []string{locale, baseLanguage(locale)} {
+		if _, ok := i.catalogs[l]; ok {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// detect 依次从查询参数、Cookie、Accept-Language 中找第一个有对应语言包的语言
+func (i *I18n) detect(c *Context) string {
+	if i.QueryParam != "" {
+		if l, ok := i.supported(c.Query(i.QueryParam)); ok {
+			return l
+		}
+	}
+	if i.CookieName != "" {
+		if cookie, err := c.Req.Cookie(i.CookieName); err == nil {
+			if l, ok := i.supported(cookie.Value); ok {
+				return l
+			}
+		}
+	}
+	for _, lang := range parseAcceptLanguage(c.Req.Header.Get("Accept-Language")) {
+		if l, ok := i.supported(lang); ok {
+			return l
+		}
+	}
+	return i.defaultLocale
+}
+
+// Middleware detects the request locale and makes c.T and c.Locale work.
+func (i *I18n) Middleware() HandlerFunc {
+	return func(c *Context) {
+		c.Set(LocaleKey, i.detect(c))
+		c.Set(i18nKey, i)
+		c.Next()
+	}
+}
+
+// FuncMap returns template helpers, to be passed to Engine.SetFuncMap:
+//
+//	{{ t .Locale "greeting" .Name }}
+func (i *I18n) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"t": i.Translate,
+	}
+}
+
+// Locale returns the locale detected by the I18n middleware.
+func (c *Context) Locale() string {
+	return c.GetString(LocaleKey)
+}
+
+// T translates key into the request locale. Without the I18n middleware it
+// returns the key.
+func (c *Context) T(key string, args ...interface{}) string {
+	v, _ := c.Get(i18nKey)
+	i, ok := v.(*I18n)
+	if !ok {
+		return key
+	}
+	return i.Translate(c.Locale(), key, args...)
+}
+
+func baseLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// parseAcceptLanguage 按 q 值从高到低返回语言列表，如 "zh-CN,zh;q=0.9,en;q=0.8"
+func parseAcceptLanguage(header string) []string {
+	type lang struct {
+		tag string
+		q   float64
+	}
+	var langs []lang
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		l := lang{tag: part, q: 1}
+		if i := strings.Index(part, ";"); i >= 0 {
+			l.tag = strings.TrimSpace(part[:i])
+			if q := strings.TrimSpace(part[i+1:]); strings.HasPrefix(q, "q=") {
+				if v, err := strconv.ParseFloat(q[2:], 64); err == nil {
+					l.q = v
+				}
+			}
+		}
+		if l.tag != "*" && l.q > 0 {
+			langs = append(langs, l)
+		}
+	}
+	sort.SliceStable(langs, func(a, b int) bool { return langs[a].q > langs[b].q })
+	tags := make([]string, len(langs))
+	for i, l := range langs {
+		tags[i] = l.tag
+	}
+	return tags
+}
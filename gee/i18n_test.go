@@ -0,0 +1,34 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestI18n(t *testing.T) {
+	i := NewI18n("en")
+	i.AddMessages("en", map[string]string{"hello": "Hello, %s"})
+	i.AddMessages("zh", map[string]string{"hello": "你好，%s"})
+
+	r := New()
+	r.Use(i.Middleware())
+	r.GET("/hello/:name", func(c *Context) {
+		c.String(http.StatusOK, c.T("hello", c.Param("name")))
+	})
+
+	testCases := map[string]string{
+		"":                        "Hello, geektutu",
+		"zh-CN,zh;q=0.9,en;q=0.8": "你好，geektutu",
+		"fr;q=0.5,en;q=0.9":       "Hello, geektutu",
+	}
+	for header, want := range testCases {
+		req := httptest.NewRequest("GET", "/hello/geektutu", nil)
+		req.Header.Set("Accept-Language", header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != want {
+			t.Errorf("Accept-Language %q should yield %q, got %q", header, want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package gee
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// cidrTrie 是按 IP 地址逐位展开的二叉前缀树，
+// 查找一个 IP 只需要走最多 32/128 层，和规则数量无关。
+type cidrTrie struct {
+	v4 *cidrNode
+	v6 *cidrNode
+}
+
+type cidrNode struct {
+	children [2]*cidrNode
+	terminal bool // 从根到这里的前缀是一条完整的 CIDR 规则
+}
+
+func newCIDRTrie(cidrs []string) (*cidrTrie, error) {
+	t := &cidrTrie{v4: &cidrNode{}, v6: &cidrNode{}}
+	for _, s := range cidrs {
+		if !strings.Contains(s, "/") {
+			if strings.Contains(s, ":") {
+				s += "/128"
+			} else {
+				s += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %v", s, err)
+		}
+		ones, _ := ipNet.Mask.Size()
+		t.insert(ipNet.IP, ones)
+	}
+	return t, nil
+}
+
+func (t *cidrTrie) root(ip net.IP) (*cidrNode, net.IP) {
+	if ip4 := ip.To4(); ip4 != nil {
+		return t.v4, ip4
+	}
+	return t.v6, ip.To16()
+}
+
+func (t *cidrTrie) insert(ip net.IP, ones int) {
+	n, ip := t.root(ip)
+	for i := 0; i < ones; i++ {
+		b := ip[i/8] >> (7 - uint(i%8)) & 1
+		if n.children[b] == nil {
+			n.children[b] = &cidrNode{}
+		}
+		n = n.children[b]
+	}
+	n.terminal = true
+}
+
+func (t *cidrTrie) contains(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	n, ip := t.root(ip)
+	for i := 0; n != nil; i++ {
+		if n.terminal {
+			return true
+		}
+		if i == len(ip)*8 {
+			break
+		}
+		n = n.children[ip[i/8]>>(7-uint(i%8))&1]
+	}
+	return false
+}
+
+// IPFilter rejects requests with 403 when ClientIP matches a deny CIDR or,
+// if allow is not empty, does not match any allow CIDR. Deny wins over allow.
+// Invalid CIDRs panic at setup time.
+func IPFilter(allow []string, deny []string) HandlerFunc {
+	allowTrie, err := newCIDRTrie(allow)
+	if err != nil {
+		panic(err)
+	}
+	denyTrie, err := newCIDRTrie(deny)
+	if err != nil {
+		panic(err)
+	}
+	return func(c *Context) {
+		ip := net.ParseIP(c.ClientIP())
+		if denyTrie.contains(ip) || (len(allow) > 0 && !allowTrie.contains(ip)) {
+			c.Forbidden("")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package gee
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCIDRTrie(t *testing.T) {
+	trie, err := newCIDRTrie([]string{"10.0.0.0/8", "192.168.1.7", "fd00::/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := map[string]bool{
+		"10.1.2.3":    true,
+		"11.0.0.1":    false,
+		"192.168.1.7": true,
+		"192.168.1.8": false,
+		"fd12::1":     true,
+		"fe80::1":     false,
+	}
+	for ip, want := range testCases {
+		if got := trie.contains(net.ParseIP(ip)); got != want {
+			t.Errorf("contains(%s) = %v, want %v", ip, got, want)
+		}
+	}
+}
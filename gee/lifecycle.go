@@ -0,0 +1,73 @@
+package gee
+
+import (
+	"context"
+	"sync"
+)
+
+// LifecycleHook runs when the engine starts or shuts down.
+type LifecycleHook func(ctx context.Context) error
+
+// lifecycle 保存启动和关闭时要执行的钩子，各执行一次
+type lifecycle struct {
+	mu         sync.Mutex
+	onStart    []LifecycleHook
+	onShutdown []LifecycleHook
+	started    bool
+	startErr   error
+	stopped    bool
+}
+
+// OnStart registers fn to run before the first Run* method starts serving,
+// e.g. to open database pools or warm caches. Hooks run in registration
+// order; if one fails, Run returns its error without serving.
+func (engine *Engine) OnStart(fn LifecycleHook) {
+	engine.lifecycle.mu.Lock()
+	defer engine.lifecycle.mu.Unlock()
+	engine.lifecycle.onStart = append(engine.lifecycle.onStart, fn)
+}
+
+// OnShutdown registers fn to run in Shutdown after in-flight requests have
+// finished, e.g. to deregister from service discovery or close pools.
+// Hooks run in reverse registration order and get Shutdown's context.
+func (engine *Engine) OnShutdown(fn LifecycleHook) {
+	engine.lifecycle.mu.Lock()
+	defer engine.lifecycle.mu.Unlock()
+	engine.lifecycle.onShutdown = append(engine.lifecycle.onShutdown, fn)
+}
+
+// start 执行 OnStart 钩子，RunMulti 这样同时启动多个服务时只执行一次
+func (engine *Engine) start() error {
+	l := &engine.lifecycle
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.started {
+		return l.startErr
+	}
+	l.started = true
+	for _, fn := range l.onStart {
+		if err := fn(context.Background()); err != nil {
+			l.startErr = err
+			return err
+		}
+	}
+	return nil
+}
+
+// stop 逆序执行 OnShutdown 钩子，先注册的资源最后释放
+func (engine *Engine) stop(ctx context.Context) error {
+	l := &engine.lifecycle
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped {
+		return nil
+	}
+	l.stopped = true
+	var firstErr error
+	for i := len(l.onShutdown) - 1; i >= 0; i-- {
+		if err := l.onShutdown[i](ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,105 @@
+package gee
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Level is the severity of a log entry.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+}
+
+// LeveledLogger is the logging backend gee writes to. keysAndValues are
+// alternating key/value pairs, so adapters for zap, slog or zerolog can map
+// them onto structured fields.
+type LeveledLogger interface {
+	Enabled(level Level) bool
+	Log(level Level, msg string, keysAndValues ...interface{})
+}
+
+type stdLogger struct {
+	l     *log.Logger
+	level Level
+	// followMode 为 true 时级别跟随运行模式：debug 模式输出 Debug，其他模式从 Info 开始
+	followMode bool
+}
+
+// NewStdLogger returns a LeveledLogger writing key=value lines to w through
+// the standard log package, dropping entries below level.
+func NewStdLogger(w io.Writer, level Level) LeveledLogger {
+	return &stdLogger{l: log.New(w, "", log.LstdFlags), level: level}
+}
+
+func defaultLogger() LeveledLogger {
+	return &stdLogger{l: log.New(os.Stderr, "", log.LstdFlags), followMode: true}
+}
+
+func (s *stdLogger) Enabled(level Level) bool {
+	if s.followMode {
+		return IsDebugging() || level >= LevelInfo
+	}
+	return level >= s.level
+}
+
+func (s *stdLogger) Log(level Level, msg string, keysAndValues ...interface{}) {
+	if !s.Enabled(level) {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("[" + level.String() + "] " + msg)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		b.WriteString(" " + fmt.Sprint(keysAndValues[i]) + "=")
+		if i+1 >= len(keysAndValues) {
+			b.WriteString("MISSING")
+			break
+		}
+		v := fmt.Sprint(keysAndValues[i+1])
+		// 带空白、换行或控制字符的值加引号转义，请求里带来的换行不能伪造出新的日志行
+		if strings.IndexFunc(v, needsQuote) >= 0 {
+			v = strconv.Quote(v)
+		}
+		b.WriteString(v)
+	}
+	s.l.Print(b.String())
+}
+
+func needsQuote(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == 0x7f
+}
+
+// SetLogger replaces the logger used for route registration, Logger and
+// Recovery.
+func (engine *Engine) SetLogger(logger LeveledLogger) {
+	engine.logger = logger
+}
+
+func (c *Context) logger() LeveledLogger {
+	if c.engine == nil || c.engine.logger == nil {
+		return defaultLogger()
+	}
+	return c.engine.logger
+}
@@ -0,0 +1,23 @@
+package gee
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStdLoggerQuotesValues(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewStdLogger(&buf, LevelDebug)
+	l.Log(LevelInfo, "request", "path", "/a\n[ERROR] forged", "user", "tom", "ua", "curl 8")
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 1 {
+		t.Fatalf("a value with a newline should stay on one line: %q", out)
+	}
+	for _, want := range []string{`path="/a\n[ERROR] forged"`, "user=tom", `ua="curl 8"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("%q should contain %s", out, want)
+		}
+	}
+}
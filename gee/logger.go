@@ -1,7 +1,15 @@
 package gee
 
 import (
-	"log"
+	"bytes"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 )
 
@@ -12,6 +20,179 @@ func Logger() HandlerFunc {
 		// Process request
 		c.Next()
 		// Calculate resolution time
-		log.Printf("[%d] %s in %v", c.StatusCode, c.Req.RequestURI, time.Since(t))
+		kv := []interface{}{"status", c.Writer.Status(), "uri", c.Req.RequestURI, "latency", time.Since(t)}
+		if id := c.RequestID(); id != "" {
+			kv = append(kv, "request_id", id)
+		}
+		c.logger().Log(LevelInfo, "request", kv...)
+	}
+}
+
+// LogFormatterParams is the data available to a custom access log format.
+type LogFormatterParams struct {
+	TimeStamp  time.Time
+	Method     string
+	Path       string
+	StatusCode int
+	Latency    time.Duration
+	ClientIP   string
+	BodySize   int // 没有写响应体时为 0
+	RequestID  string
+	// Color 表示是否输出终端颜色
+	Color bool
+}
+
+// LogFormatter turns one request into one access log line.
+type LogFormatter func(params LogFormatterParams) string
+
+// LoggerConfig configures LoggerWithConfig.
+type LoggerConfig struct {
+	// Formatter 为空时使用 Template，两者都为空时使用 defaultLogFormatter
+	Formatter LogFormatter
+	// Template 是 text/template 模板，数据是 LogFormatterParams
+	Template *template.Template
+	// Output 默认是 os.Stderr
+	Output io.Writer
+	// Color 开启后默认格式会给状态码和方法加上终端颜色
+	Color bool
+	// SkipPaths 中的路径（精确匹配）不记录日志，比如 /healthz、/metrics
+	SkipPaths []string
+	// SampleRates 按路径前缀设置采样率（0~1），匹配最长的前缀；
+	// 5xx 响应总是记录
+	SampleRates map[string]float64
+}
+
+// sampled 判断这次请求是否需要记录
+func (conf *LoggerConfig) sampled(path string, status int) bool {
+	if status >= http.StatusInternalServerError {
+		return true
+	}
+	prefix, rate := "", 1.0
+	for p, r := range conf.SampleRates {
+		if strings.HasPrefix(path, p) && len(p) >= len(prefix) {
+			prefix, rate = p, r
+		}
+	}
+	return rate >= 1 || rand.Float64() < rate
+}
+
+const (
+	green   = "\033[97;42m"
+	white   = "\033[90;47m"
+	yellow  = "\033[90;43m"
+	red     = "\033[97;41m"
+	blue    = "\033[97;44m"
+	magenta = "\033[97;45m"
+	cyan    = "\033[97;46m"
+	reset   = "\033[0m"
+)
+
+// StatusCodeColor returns the ANSI color used for the status code.
+func (p LogFormatterParams) StatusCodeColor() string {
+	switch code := p.StatusCode; {
+	case code >= http.StatusOK && code < http.StatusMultipleChoices:
+		return green
+	case code >= http.StatusMultipleChoices && code < http.StatusBadRequest:
+		return white
+	case code >= http.StatusBadRequest && code < http.StatusInternalServerError:
+		return yellow
+	default:
+		return red
+	}
+}
+
+// MethodColor returns the ANSI color used for the method.
+func (p LogFormatterParams) MethodColor() string {
+	switch p.Method {
+	case http.MethodGet:
+		return blue
+	case http.MethodPost:
+		return cyan
+	case http.MethodPut, http.MethodPatch:
+		return yellow
+	case http.MethodDelete:
+		return red
+	case http.MethodHead:
+		return magenta
+	default:
+		return reset
+	}
+}
+
+func defaultLogFormatter(p LogFormatterParams) string {
+	statusColor, methodColor, resetColor := "", "", ""
+	if p.Color {
+		statusColor, methodColor, resetColor = p.StatusCodeColor(), p.MethodColor(), reset
+	}
+	return fmt.Sprintf("[GEE] %v |%s %3d %s| %13v | %15s | %6d |%s %-7s %s %q %s\n",
+		p.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		statusColor, p.StatusCode, resetColor,
+		p.Latency,
+		p.ClientIP,
+		p.BodySize,
+		methodColor, p.Method, resetColor,
+		p.Path,
+		p.RequestID,
+	)
+}
+
+// LoggerWithConfig is an access logger with a configurable line format and
+// output, for logs that are read by people or parsed by other tools.
+func LoggerWithConfig(conf LoggerConfig) HandlerFunc {
+	formatter := conf.Formatter
+	if formatter == nil && conf.Template != nil {
+		tmpl := conf.Template
+		formatter = func(p LogFormatterParams) string {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, p); err != nil {
+				return fmt.Sprintf("[GEE] log template error: %v\n", err)
+			}
+			return buf.String()
+		}
+	}
+	if formatter == nil {
+		formatter = defaultLogFormatter
+	}
+	out := conf.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	skip := make(map[string]bool, len(conf.SkipPaths))
+	for _, p := range conf.SkipPaths {
+		skip[p] = true
+	}
+	// mu 保证并发请求的日志行整行写入 out，不会交错
+	var mu sync.Mutex
+
+	return func(c *Context) {
+		path := c.Req.URL.Path
+		if skip[path] {
+			c.Next()
+			return
+		}
+		t := time.Now()
+		c.Next()
+		if !conf.sampled(path, c.Writer.Status()) {
+			return
+		}
+
+		size := c.Writer.Size()
+		if size < 0 {
+			size = 0
+		}
+		line := formatter(LogFormatterParams{
+			TimeStamp:  t,
+			Method:     c.Method,
+			Path:       c.Req.URL.RequestURI(),
+			StatusCode: c.Writer.Status(),
+			Latency:    time.Since(t),
+			ClientIP:   c.ClientIP(),
+			BodySize:   size,
+			RequestID:  c.RequestID(),
+			Color:      conf.Color,
+		})
+		mu.Lock()
+		io.WriteString(out, line)
+		mu.Unlock()
 	}
 }
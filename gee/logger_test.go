@@ -0,0 +1,66 @@
+package gee
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestLoggerSkipAndSample(t *testing.T) {
+	var out bytes.Buffer
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{
+		Output:      &out,
+		SkipPaths:   []string{"/healthz"},
+		SampleRates: map[string]float64{"/api": 0, "/api/orders": 1},
+	}))
+	r.GET("/healthz", func(c *Context) { c.String(http.StatusOK, "ok") })
+	r.GET("/api/users", func(c *Context) { c.String(http.StatusOK, "ok") })
+	r.GET("/api/orders", func(c *Context) { c.String(http.StatusOK, "ok") })
+	r.GET("/api/fail", func(c *Context) { c.Status(http.StatusInternalServerError) })
+
+	for _, path := range []string{"/healthz", "/api/users", "/api/orders", "/api/fail"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	logs := out.String()
+	for path, want := range map[string]bool{
+		"/healthz":    false,
+		"/api/users":  false, // 采样率为 0
+		"/api/orders": true,  // 更长的前缀优先
+		"/api/fail":   true,  // 5xx 总是记录
+	} {
+		if got := strings.Contains(logs, `"`+path+`"`); got != want {
+			t.Fatalf("%s logged = %v, want %v:\n%s", path, got, want, logs)
+		}
+	}
+}
+
+func TestLoggerConcurrentWrites(t *testing.T) {
+	var out bytes.Buffer
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{
+		Output: &out,
+		Formatter: func(p LogFormatterParams) string {
+			return fmt.Sprintf("%s %d\n", p.Path, p.BodySize)
+		},
+	}))
+	r.GET("/empty", func(c *Context) { c.Status(http.StatusNoContent) })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/empty", nil))
+		}()
+	}
+	wg.Wait()
+	// 没有响应体时 BodySize 是 0 而不是 -1，每个请求一整行
+	if want := strings.Repeat("/empty 0\n", 20); out.String() != want {
+		t.Fatalf("unexpected logs:\n%s", out.String())
+	}
+}
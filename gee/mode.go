@@ -0,0 +1,49 @@
+package gee
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// EnvGeeMode is the environment variable the initial mode is read from.
+const EnvGeeMode = "GEE_MODE"
+
+const (
+	// DebugMode 会打印路由注册日志、每次渲染重新加载模板、JSON 缩进输出，
+	// panic 时把错误信息返回给客户端，只应在开发时通过 GEE_MODE=debug 打开
+	DebugMode = "debug"
+	// ReleaseMode 用于生产环境，是默认模式
+	ReleaseMode = "release"
+	// TestMode 和 ReleaseMode 行为一致，用于测试
+	TestMode = "test"
+)
+
+var geeMode atomic.Value
+
+func init() {
+	SetMode(os.Getenv(EnvGeeMode))
+}
+
+// SetMode sets the package mode. An empty value means ReleaseMode, so
+// panic messages and error details don't reach clients unless DebugMode is
+// chosen explicitly.
+func SetMode(value string) {
+	switch value {
+	case "":
+		value = ReleaseMode
+	case DebugMode, ReleaseMode, TestMode:
+	default:
+		panic("gee mode unknown: " + value + " (available mode: debug release test)")
+	}
+	geeMode.Store(value)
+}
+
+// Mode returns the current mode.
+func Mode() string {
+	return geeMode.Load().(string)
+}
+
+// IsDebugging reports whether gee runs in DebugMode.
+func IsDebugging() bool {
+	return Mode() == DebugMode
+}
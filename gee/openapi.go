@@ -0,0 +1,256 @@
+package gee
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RouteDoc describes a route in the document built by OpenAPISpec.
+// Request and Response are values of the body types, e.g. CreateUser{};
+// their schemas come from the json tags, and fields tagged
+// binding:"required" are listed as required.
+type RouteDoc struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Request     interface{}
+	Response    interface{}
+}
+
+// Doc attaches documentation to the route:
+//
+//	r.POST("/users", createUser).Doc(gee.RouteDoc{
+//		Summary:  "Create a user",
+//		Request:  CreateUser{},
+//		Response: User{},
+//	})
+func (rt *Route) Doc(doc RouteDoc) *Route {
+	rt.doc = &doc
+	return rt
+}
+
+// OpenAPIInfo is the info object of the OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPISpec returns an OpenAPI 3 document for the routes registered so
+// far, ready to be encoded as JSON. Routes without a Doc are listed with
+// their path parameters only.
+func (engine *Engine) OpenAPISpec(info OpenAPIInfo) H {
+	routes := make([]*Route, 0, len(engine.router.routes))
+	for _, rt := range engine.router.routes {
+		routes = append(routes, rt)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].pattern != routes[j].pattern {
+			return routes[i].pattern < routes[j].pattern
+		}
+		return routes[i].method < routes[j].method
+	})
+
+	schemas := H{}
+	paths := H{}
+	for _, rt := range routes {
+		path, params := openAPIPath(rt.pattern)
+		item, ok := paths[path].(H)
+		if !ok {
+			item = H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.method)] = openAPIOperation(rt, params, schemas)
+	}
+
+	spec := H{
+		"openapi": "3.0.3",
+		"info":    info,
+		"paths":   paths,
+	}
+	if len(schemas) > 0 {
+		spec["components"] = H{"schemas": schemas}
+	}
+	return spec
+}
+
+// SwaggerUI serves the OpenAPI document at path+"/openapi.json" and a
+// Swagger UI page for it at path, e.g. r.SwaggerUI("/docs", info). The
+// page loads Swagger UI from the unpkg CDN.
+func (engine *Engine) SwaggerUI(path string, info OpenAPIInfo) {
+	path = strings.TrimSuffix(path, "/")
+	engine.GET(path+"/openapi.json", func(c *Context) {
+		c.JSON(http.StatusOK, engine.OpenAPISpec(info))
+	})
+	engine.GET(path, func(c *Context) {
+		c.SetHeader("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		c.Writer.Write([]byte(strings.Replace(swaggerUIPage, "{{spec}}", path+"/openapi.json", 1)))
+	})
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>API docs</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>SwaggerUIBundle({url: "{{spec}}", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+// openAPIPath 把 /users/:id 和 /assets/*filepath 转成 OpenAPI 的 {id} 形式，并返回参数名
+func openAPIPath(pattern string) (string, []string) {
+	parts := parsePattern(pattern)
+	var params []string
+	for i, part := range parts {
+		if part[0] == ':' || (part[0] == '*' && len(part) > 1) {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+	return "/" + strings.Join(parts, "/"), params
+}
+
+func openAPIOperation(rt *Route, params []string, schemas H) H {
+	op := H{}
+	if len(params) > 0 {
+		list := make([]H, 0, len(params))
+		for _, name := range params {
+			list = append(list, H{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   H{"type": "string"},
+			})
+		}
+		op["parameters"] = list
+	}
+	response := H{"description": http.StatusText(http.StatusOK)}
+	doc := rt.doc
+	if doc == nil {
+		op["responses"] = H{"200": response}
+		return op
+	}
+	if doc.Summary != "" {
+		op["summary"] = doc.Summary
+	}
+	if doc.Description != "" {
+		op["description"] = doc.Description
+	}
+	if len(doc.Tags) > 0 {
+		op["tags"] = doc.Tags
+	}
+	if doc.Request != nil {
+		op["requestBody"] = H{
+			"required": true,
+			"content": H{"application/json": H{
+				"schema": openAPISchema(reflect.TypeOf(doc.Request), schemas),
+			}},
+		}
+	}
+	if doc.Response != nil {
+		response["content"] = H{"application/json": H{
+			"schema": openAPISchema(reflect.TypeOf(doc.Response), schemas),
+		}}
+	}
+	op["responses"] = H{"200": response}
+	return op
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// openAPISchema 生成类型的 schema，具名结构体放进 components 里通过 $ref 引用，
+// 这样递归类型也不会无限展开
+func openAPISchema(t reflect.Type, schemas H) H {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return H{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return H{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return H{"type": "number"}
+	case reflect.String:
+		return H{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return H{"type": "string", "format": "byte"}
+		}
+		return H{"type": "array", "items": openAPISchema(t.Elem(), schemas)}
+	case reflect.Map:
+		return H{"type": "object", "additionalProperties": openAPISchema(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return openAPIObject(t, schemas)
+		}
+		ref := H{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; !ok {
+			// 先占位，结构体引用自己时直接返回 $ref
+			schemas[t.Name()] = H{}
+			schemas[t.Name()] = openAPIObject(t, schemas)
+		}
+		return ref
+	}
+	return H{}
+}
+
+func openAPIObject(t reflect.Type, schemas H) H {
+	properties := H{}
+	var required []string
+	openAPIFields(t, schemas, properties, &required)
+	schema := H{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func openAPIFields(t reflect.Type, schemas H, properties H, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// 和 encoding/json 一样，嵌入的结构体字段展开到外层
+		if f.Anonymous && f.Tag.Get("json") == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				openAPIFields(ft, schemas, properties, required)
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		properties[name] = openAPISchema(f.Type, schemas)
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+			if rule == "required" {
+				*required = append(*required, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package gee
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type openAPIUser struct {
+	ID      string       `json:"id"`
+	Name    string       `json:"name" binding:"required"`
+	Tags    []string     `json:"tags,omitempty"`
+	Manager *openAPIUser `json:"manager,omitempty"`
+	secret  string
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	r := New()
+	api := r.Group("/api")
+	api.GET("/users/:id", func(c *Context) {}).Doc(RouteDoc{
+		Summary:  "Get a user",
+		Response: openAPIUser{},
+	})
+	api.POST("/users", func(c *Context) {}).Doc(RouteDoc{Request: &openAPIUser{}})
+	r.SwaggerUI("/docs", OpenAPIInfo{Title: "users", Version: "1.0"})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var spec struct {
+		Paths map[string]map[string]struct {
+			Summary    string `json:"summary"`
+			Parameters []struct {
+				Name string `json:"name"`
+				In   string `json:"in"`
+			} `json:"parameters"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                          `json:"required"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	get := spec.Paths["/api/users/{id}"]["get"]
+	if get.Summary != "Get a user" || len(get.Parameters) != 1 || get.Parameters[0].Name != "id" {
+		t.Fatalf("get operation = %+v", get)
+	}
+	if _, ok := spec.Paths["/api/users"]["post"]; !ok {
+		t.Fatal("post operation missing")
+	}
+	user := spec.Components.Schemas["openAPIUser"]
+	if !reflect.DeepEqual(user.Required, []string{"name"}) {
+		t.Fatalf("required = %v", user.Required)
+	}
+	if _, ok := user.Properties["secret"]; ok || len(user.Properties) != 4 {
+		t.Fatalf("properties = %v", user.Properties)
+	}
+	if user.Properties["manager"]["$ref"] != "#/components/schemas/openAPIUser" {
+		t.Fatalf("manager = %v", user.Properties["manager"])
+	}
+}
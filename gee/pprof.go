@@ -0,0 +1,28 @@
+package gee
+
+import "net/http/pprof"
+
+// DefaultPprofPrefix is where RegisterPprof mounts the handlers by default.
+const DefaultPprofPrefix = "/debug/pprof"
+
+// RegisterPprof mounts the net/http/pprof handlers under group, at prefix
+// (DefaultPprofPrefix when empty). Put auth middleware on the group first:
+//
+//	admin := r.Group("/admin")
+//	admin.Use(auth)
+//	gee.RegisterPprof(admin, "")
+func RegisterPprof(group *RouterGroup, prefix string) {
+	if prefix == "" {
+		prefix = DefaultPprofPrefix
+	}
+	group.GET(prefix+"/", WrapF(pprof.Index))
+	group.GET(prefix+"/cmdline", WrapF(pprof.Cmdline))
+	group.GET(prefix+"/profile", WrapF(pprof.Profile))
+	group.GET(prefix+"/symbol", WrapF(pprof.Symbol))
+	group.POST(prefix+"/symbol", WrapF(pprof.Symbol))
+	group.GET(prefix+"/trace", WrapF(pprof.Trace))
+	// pprof.Index 只认 /debug/pprof/ 前缀，挂在其他分组下时具名的 profile 要单独注册
+	for _, name := range []string{"allocs", "block", "goroutine", "heap", "mutex", "threadcreate"} {
+		group.GET(prefix+"/"+name, WrapH(pprof.Handler(name)))
+	}
+}
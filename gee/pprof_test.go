@@ -0,0 +1,44 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterPprof(t *testing.T) {
+	r := New()
+	admin := r.Group("/admin")
+	admin.Use(func(c *Context) {
+		if c.Req.Header.Get("Authorization") != "Bearer root" {
+			c.Unauthorized("admin")
+		}
+	})
+	RegisterPprof(admin, "")
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/debug/pprof/heap?debug=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("pprof should be behind the group's middleware, got %d", w.Code)
+	}
+
+	for _, path := range []string{"/admin/debug/pprof/", "/admin/debug/pprof/heap?debug=1", "/admin/debug/pprof/cmdline"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer root")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Fatalf("%s: %d %q", path, w.Code, w.Body.String())
+		}
+	}
+	// 具名 profile 在其他前缀下也能取到
+	req = httptest.NewRequest(http.MethodGet, "/admin/debug/pprof/goroutine?debug=1", nil)
+	req.Header.Set("Authorization", "Bearer root")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if !strings.Contains(w.Body.String(), "goroutine profile") {
+		t.Fatalf("goroutine profile: %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,53 @@
+package gee
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MIMEProtoBuf is the Content-Type of protobuf bodies.
+const MIMEProtoBuf = "application/x-protobuf"
+
+// defaultMaxProtoBufSize 是没有设置 MaxRequestBodySize 时 protobuf 请求体的上限，
+// 和 gRPC 默认的消息大小上限一样
+const defaultMaxProtoBufSize = 4 << 20
+
+// ProtoBuf writes msg in the protobuf wire format.
+func (c *Context) ProtoBuf(code int, msg proto.Message) {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.SetHeader("Content-Type", MIMEProtoBuf)
+	c.Status(code)
+	c.Writer.Write(data)
+}
+
+// BindProtoBuf decodes an application/x-protobuf body into msg. On failure
+// it responds like Bind.
+func (c *Context) BindProtoBuf(msg proto.Message) error {
+	return c.bindOrFail(c.ShouldBindProtoBuf(msg))
+}
+
+// ShouldBindProtoBuf decodes the protobuf body into msg. Without a
+// MaxRequestBodySize limit bodies are capped at 4 MiB, as protobuf has to
+// be read whole before decoding.
+func (c *Context) ShouldBindProtoBuf(msg proto.Message) error {
+	if c.Req.Body == nil {
+		return errors.New("gee: empty request body")
+	}
+	// 设置了 MaxRequestBodySize 时请求体已经由它限制
+	body := c.Req.Body
+	if c.maxBodySize() == 0 {
+		body = http.MaxBytesReader(c.Writer, body, defaultMaxProtoBufSize)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, msg)
+}
@@ -0,0 +1,70 @@
+package gee
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtoBuf(t *testing.T) {
+	r := New()
+	r.POST("/echo", func(c *Context) {
+		var msg wrapperspb.StringValue
+		if c.BindProtoBuf(&msg) != nil {
+			return
+		}
+		c.ProtoBuf(http.StatusOK, wrapperspb.String("hello "+msg.GetValue()))
+	})
+
+	body, _ := proto.Marshal(wrapperspb.String("gee"))
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", MIMEProtoBuf)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != MIMEProtoBuf {
+		t.Fatalf("status %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	var resp wrapperspb.StringValue
+	if err := proto.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.GetValue() != "hello gee" {
+		t.Fatalf("response %q, %v", resp.GetValue(), err)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader([]byte{0xff}))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid body: status %d", w.Code)
+	}
+}
+
+func TestProtoBufDefaultLimit(t *testing.T) {
+	r := New()
+	r.POST("/echo", func(c *Context) {
+		var msg wrapperspb.BytesValue
+		if c.BindProtoBuf(&msg) != nil {
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	body, _ := proto.Marshal(wrapperspb.Bytes(make([]byte, defaultMaxProtoBufSize)))
+	req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+	req.Header.Set("Content-Type", MIMEProtoBuf)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("bodies over the default limit should get 413, got %d", w.Code)
+	}
+
+	r.MaxRequestBodySize(NoBodyLimit)
+	req = httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("NoBodyLimit should lift the default limit, got %d", w.Code)
+	}
+}
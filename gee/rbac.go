@@ -0,0 +1,87 @@
+package gee
+
+import "strings"
+
+// SubjectKey is the context key auth middleware stores the Subject under.
+const SubjectKey = "gee/subject"
+
+// Subject is the authenticated caller, set by auth middleware with
+// c.Set(gee.SubjectKey, subject).
+type Subject struct {
+	ID    string
+	Roles []string
+}
+
+// Subject returns the Subject set by auth middleware.
+func (c *Context) Subject() (Subject, bool) {
+	v, ok := c.Get(SubjectKey)
+	if !ok {
+		return Subject{}, false
+	}
+	s, ok := v.(Subject)
+	return s, ok
+}
+
+// Policy decides whether subject may call method on route, the matched
+// route pattern (see Context.FullPath). Implement it to plug in a custom
+// policy engine.
+type Policy interface {
+	Authorize(subject Subject, method string, route string) bool
+}
+
+// RBAC is a Policy granting roles access to route patterns.
+type RBAC struct {
+	permissions map[string][]permission
+}
+
+type permission struct {
+	method  string
+	pattern string
+}
+
+// NewRBAC returns an RBAC policy without any permission.
+func NewRBAC() *RBAC {
+	return &RBAC{permissions: make(map[string][]permission)}
+}
+
+// Allow grants role access to method ("*" for any) on pattern. pattern is a
+// route pattern like "/api/posts/:id", or ends with "/*" to cover every
+// route below a prefix.
+func (r *RBAC) Allow(role string, method string, pattern string) *RBAC {
+	r.permissions[role] = append(r.permissions[role], permission{method: method, pattern: pattern})
+	return r
+}
+
+func (r *RBAC) Authorize(subject Subject, method string, route string) bool {
+	if route == "" {
+		return false
+	}
+	for _, role := range subject.Roles {
+		for _, p := range r.permissions[role] {
+			if p.method != "*" && p.method != method {
+				continue
+			}
+			if p.pattern == route {
+				return true
+			}
+			// /admin/* 覆盖 /admin 下所有路由
+			if strings.HasSuffix(p.pattern, "/*") && strings.HasPrefix(route+"/", strings.TrimSuffix(p.pattern, "*")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize rejects requests with 403 unless policy allows the current
+// Subject to call the matched route. Register it after the auth middleware.
+func Authorize(policy Policy) HandlerFunc {
+	return func(c *Context) {
+		subject, ok := c.Subject()
+		if !ok || !policy.Authorize(subject, c.Method, c.FullPath()) {
+			c.Forbidden("")
+			return
+		}
+		c.Next()
+	}
+}
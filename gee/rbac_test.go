@@ -0,0 +1,26 @@
+package gee
+
+import "testing"
+
+func TestRBAC(t *testing.T) {
+	rbac := NewRBAC().
+		Allow("editor", "POST", "/api/posts/:id").
+		Allow("admin", "*", "/admin/*")
+
+	testCases := []struct {
+		roles         []string
+		method, route string
+		allowed       bool
+	}{
+		{[]string{"editor"}, "POST", "/api/posts/:id", true},
+		{[]string{"editor"}, "DELETE", "/api/posts/:id", false},
+		{[]string{"admin"}, "DELETE", "/admin/users/:id", true},
+		{[]string{"admin"}, "GET", "/administrator", false},
+		{nil, "GET", "/admin/users", false},
+	}
+	for _, tc := range testCases {
+		if got := rbac.Authorize(Subject{Roles: tc.roles}, tc.method, tc.route); got != tc.allowed {
+			t.Errorf("%v %s %s: allowed = %v, want %v", tc.roles, tc.method, tc.route, got, tc.allowed)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package gee
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
 	"runtime"
+	"runtime/debug"
 	"strings"
+	"syscall"
 )
 
 func trace(message string) string {
@@ -29,16 +31,123 @@ func trace(message string) string {
 	return str.String()
 }
 
+// RecoveryFunc writes the response after a panic has been recovered and
+// logged, e.g. a JSON error envelope for APIs or an HTML page for browsers.
+type RecoveryFunc func(c *Context, err interface{})
+
+func defaultRecoveryHandler(c *Context, err interface{}) {
+	// debug 模式下把 panic 信息直接返回，方便开发时定位问题
+	if IsDebugging() {
+		c.Fail(http.StatusInternalServerError, fmt.Sprintf("%s", err))
+		return
+	}
+	c.Fail(http.StatusInternalServerError, "INternal Server Error")
+}
+
 func Recovery() HandlerFunc {
+	return RecoveryWith(defaultRecoveryHandler)
+}
+
+// RecoveryWith recovers from panics and lets handle write the response.
+// Panics caused by the client going away (broken pipe, connection reset)
+// are only logged: the connection is dead, so no 500 is written.
+func RecoveryWith(handle RecoveryFunc) HandlerFunc {
+	return RecoveryWithConfig(RecoveryConfig{Handler: handle})
+}
+
+// RecoveryConfig configures RecoveryWithConfig.
+type RecoveryConfig struct {
+	// Handler 写出 panic 之后的响应，默认返回 500；
+	// 路由分组通过 SetPanicHandler 设置的处理优先
+	Handler RecoveryFunc
+	// ReportPanic 用于把 panic 转发给 Sentry、Rollbar 或告警系统，
+	// 客户端断开导致的 panic 不会上报
+	ReportPanic func(err interface{}, stack []byte, c *Context)
+}
+
+// RecoveryWithConfig is Recovery with a custom response handler and an
+// optional panic reporter.
+func RecoveryWithConfig(conf RecoveryConfig) HandlerFunc {
+	handle := conf.Handler
+	if handle == nil {
+		handle = defaultRecoveryHandler
+	}
 	return func(c *Context) {
 		defer func() {
 			if err := recover(); err != nil {
 				message := fmt.Sprintf("%s", err)
-				log.Printf("%s\n\n", trace(message))
-				c.Fail(http.StatusInternalServerError, "INternal Server Error")
+				if isBrokenPipe(err) {
+					c.logger().Log(LevelWarn, "client disconnected", "error", message, "path", c.Path)
+					c.index = len(c.handlers)
+					return
+				}
+				c.logger().Log(LevelError, "panic recovered",
+					"error", message,
+					"method", c.Method,
+					"path", c.Path,
+					"headers", dumpHeaders(c.Req.Header, defaultRedact),
+					"trace", trace(message))
+				if conf.ReportPanic != nil {
+					reportPanic(conf.ReportPanic, err, c)
+				}
+				if h := c.panicHandler(); h != nil {
+					h(c, err)
+				} else {
+					handle(c, err)
+				}
+				// 不管 handle 怎么写响应，都不再执行后续的处理函数
+				c.index = len(c.handlers)
 			}
 		}()
 
 		c.Next()
 	}
 }
+
+// reportPanic 上报回调自己 panic 时只记录日志，不影响给客户端的响应
+func reportPanic(report func(interface{}, []byte, *Context), err interface{}, c *Context) {
+	defer func() {
+		if e := recover(); e != nil {
+			c.logger().Log(LevelError, "panic reporter failed", "error", fmt.Sprintf("%s", e))
+		}
+	}()
+	report(err, debug.Stack(), c)
+}
+
+// RecoveryTemplate returns a RecoveryFunc rendering the HTML template name
+// (e.g. "errors/500") loaded with LoadHTMLGlob. In debug mode the template
+// gets .Error, .Trace, .Method and .Path; otherwise only .Status and
+// .StatusText, so nothing internal leaks. Without such a template it falls
+// back to the default response.
+func RecoveryTemplate(name string) RecoveryFunc {
+	return func(c *Context, err interface{}) {
+		tmpl, tmplErr := c.engine.templates()
+		if tmplErr != nil || tmpl == nil || tmpl.Lookup(name) == nil {
+			defaultRecoveryHandler(c, err)
+			return
+		}
+		data := H{
+			"Status":     http.StatusInternalServerError,
+			"StatusText": http.StatusText(http.StatusInternalServerError),
+		}
+		if IsDebugging() {
+			data["Error"] = fmt.Sprintf("%s", err)
+			// 仍在 panic 的 defer 中，调用栈里包含 panic 发生的位置
+			data["Trace"] = string(debug.Stack())
+			data["Method"] = c.Method
+			data["Path"] = c.Path
+		}
+		c.HTML(http.StatusInternalServerError, name, data)
+	}
+}
+
+// isBrokenPipe 判断 panic 是否因为客户端断开连接，这时再写 500 没有意义
+func isBrokenPipe(err interface{}) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
+	}
+	return errors.Is(e, http.ErrAbortHandler) ||
+		errors.Is(e, syscall.EPIPE) ||
+		errors.Is(e, syscall.ECONNRESET)
+}
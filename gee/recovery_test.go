@@ -0,0 +1,99 @@
+package gee
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryWith(t *testing.T) {
+	r := New()
+	calls := 0
+	r.Use(RecoveryWith(func(c *Context, err interface{}) {
+		calls++
+		c.JSON(http.StatusInternalServerError, H{"error": "oops"})
+	}))
+	r.GET("/panic", func(c *Context) { panic("boom") })
+	r.GET("/gone", func(c *Context) { panic(http.ErrAbortHandler) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError || w.Body.String() != `{"error":"oops"}`+"\n" || calls != 1 {
+		t.Fatalf("custom handler should write the response, got %d %q", w.Code, w.Body.String())
+	}
+
+	// 客户端已经断开，不再写 500
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/gone", nil))
+	if calls != 1 || w.Body.Len() != 0 {
+		t.Fatalf("client disconnects should not get a response, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestRecoveryTemplate(t *testing.T) {
+	defer SetMode(Mode())
+	dir := t.TempDir()
+	page := `{{define "errors/500"}}{{.Status}} {{.StatusText}}{{with .Error}} error={{.}}{{end}}{{end}}`
+	if err := os.WriteFile(filepath.Join(dir, "500.tmpl"), []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.LoadHTMLGlob(filepath.Join(dir, "*.tmpl"))
+	r.Use(RecoveryWith(RecoveryTemplate("errors/500")))
+	r.GET("/panic", func(c *Context) { panic("db password leaked") })
+
+	for _, tt := range []struct {
+		mode string
+		want string
+	}{
+		{ReleaseMode, "500 Internal Server Error"},
+		{DebugMode, "500 Internal Server Error error=db password leaked"},
+	} {
+		SetMode(tt.mode)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+		if w.Code != http.StatusInternalServerError || strings.TrimSpace(w.Body.String()) != tt.want {
+			t.Fatalf("%s mode: got %d %q", tt.mode, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestRecoveryReportPanic(t *testing.T) {
+	var logs bytes.Buffer
+	r := New()
+	r.SetLogger(NewStdLogger(&logs, LevelInfo))
+	var reported []interface{}
+	r.Use(RecoveryWithConfig(RecoveryConfig{
+		ReportPanic: func(err interface{}, stack []byte, c *Context) {
+			if len(stack) == 0 || c.Path != "/panic" {
+				t.Errorf("reporter should get the stack and context")
+			}
+			reported = append(reported, err)
+			panic("reporter down")
+		},
+	}))
+	r.GET("/panic", func(c *Context) { panic("boom") })
+	r.GET("/gone", func(c *Context) { panic(http.ErrAbortHandler) })
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	// 上报回调自己 panic 也不影响响应
+	if w.Code != http.StatusInternalServerError || len(reported) != 1 || reported[0] != "boom" {
+		t.Fatalf("got %d, reported %v", w.Code, reported)
+	}
+	out := logs.String()
+	if !strings.Contains(out, "method=GET") || !strings.Contains(out, "[REDACTED]") || strings.Contains(out, "s3cret") {
+		t.Fatalf("the log should carry the request with redacted headers:\n%s", out)
+	}
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/gone", nil))
+	if len(reported) != 1 {
+		t.Fatalf("client disconnects should not be reported, got %v", reported)
+	}
+}
@@ -0,0 +1,98 @@
+package gee
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RequestRecord is the summary of one request kept by ReplayBuffer.
+type RequestRecord struct {
+	Time      time.Time
+	Method    string
+	Path      string
+	Status    int
+	Latency   time.Duration
+	RequestID string
+	Errors    []string
+}
+
+// ReplayBuffer keeps the last N request summaries in a ring buffer so recent
+// traffic can be inspected from a debug endpoint.
+type ReplayBuffer struct {
+	mu      sync.Mutex
+	records []RequestRecord
+	next    int // 下一条记录写入的位置
+	full    bool
+}
+
+// NewReplayBuffer creates a ReplayBuffer holding at most size records.
+func NewReplayBuffer(size int) *ReplayBuffer {
+	if size <= 0 {
+		size = 1
+	}
+	return &ReplayBuffer{records: make([]RequestRecord, size)}
+}
+
+func (b *ReplayBuffer) add(r RequestRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.records[b.next] = r
+	b.next = (b.next + 1) % len(b.records)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Records returns the buffered records, newest first.
+func (b *ReplayBuffer) Records() []RequestRecord {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.next
+	if b.full {
+		n = len(b.records)
+	}
+	out := make([]RequestRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, b.records[(b.next-i+len(b.records))%len(b.records)])
+	}
+	return out
+}
+
+// Middleware records a summary of every request passing through it.
+func (b *ReplayBuffer) Middleware() HandlerFunc {
+	return func(c *Context) {
+		t := time.Now()
+		c.Next()
+		b.add(RequestRecord{
+			Time:      t,
+			Method:    c.Method,
+			Path:      c.Req.URL.RequestURI(),
+			Status:    c.Writer.Status(),
+			Latency:   time.Since(t),
+			RequestID: c.RequestID(),
+			Errors:    c.errorStrings(),
+		})
+	}
+}
+
+// Handler serves the buffered records as JSON, e.g.
+// r.GET("/debug/requests", buf.Handler()).
+func (b *ReplayBuffer) Handler() HandlerFunc {
+	return func(c *Context) {
+		records := b.Records()
+		out := make([]H, 0, len(records))
+		for _, r := range records {
+			out = append(out, H{
+				"time":       r.Time.Format(time.RFC3339Nano),
+				"method":     r.Method,
+				"path":       r.Path,
+				"status":     r.Status,
+				"latency":    r.Latency.String(),
+				"request_id": r.RequestID,
+				"errors":     r.Errors,
+			})
+		}
+		c.JSON(http.StatusOK, out)
+	}
+}
@@ -0,0 +1,44 @@
+package gee
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReplayBuffer(t *testing.T) {
+	buf := NewReplayBuffer(2)
+	r := New()
+	r.GET("/debug/requests", buf.Handler())
+	api := r.Group("/api")
+	api.Use(buf.Middleware())
+	api.GET("/:id", func(c *Context) {
+		if c.Param("id") == "bad" {
+			c.Error(errors.New("boom"))
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.String(http.StatusOK, "ok")
+	})
+
+	for _, path := range []string{"/api/1", "/api/2", "/api/bad"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	// 容量是 2，只保留最近两条，最新的在前
+	records := buf.Records()
+	if len(records) != 2 || records[0].Path != "/api/bad" || records[1].Path != "/api/2" {
+		t.Fatalf("records = %+v", records)
+	}
+	if records[0].Status != http.StatusInternalServerError || len(records[0].Errors) != 1 || records[0].Errors[0] != "boom" {
+		t.Fatalf("failed request should keep its status and errors, got %+v", records[0])
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/debug/requests", nil))
+	var out []H
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil || len(out) != 2 || out[0]["path"] != "/api/bad" {
+		t.Fatalf("debug endpoint returned %q", w.Body.String())
+	}
+}
@@ -0,0 +1,43 @@
+package gee
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+const (
+	// HeaderXRequestID is the header carrying the request ID between services.
+	HeaderXRequestID = "X-Request-ID"
+	// RequestIDKey is the context key RequestID stores the ID under.
+	RequestIDKey = "gee/request-id"
+
+	maxRequestIDLen = 128
+)
+
+// RequestID reuses the caller's X-Request-ID (or generates a new one), stores
+// it on the Context and echoes it in the response header.
+func RequestID() HandlerFunc {
+	return func(c *Context) {
+		id := c.Req.Header.Get(HeaderXRequestID)
+		// 不信任过长的外部 ID，避免被塞进日志
+		if id == "" || len(id) > maxRequestIDLen {
+			id = newRequestID()
+		}
+		c.Set(RequestIDKey, id)
+		c.SetHeader(HeaderXRequestID, id)
+		c.Next()
+	}
+}
+
+// RequestID returns the ID set by the RequestID middleware, if any.
+func (c *Context) RequestID() string {
+	return c.GetString(RequestIDKey)
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,43 @@
+package gee
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	var logs bytes.Buffer
+	r := New()
+	r.SetLogger(NewStdLogger(&logs, LevelInfo))
+	r.Use(RequestID(), Logger())
+	var seen string
+	r.GET("/ping", func(c *Context) {
+		seen = c.RequestID()
+		c.String(http.StatusOK, "pong")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(HeaderXRequestID, "abc-123")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if seen != "abc-123" || w.Header().Get(HeaderXRequestID) != "abc-123" {
+		t.Fatalf("the caller's ID should be kept, got %q %q", seen, w.Header().Get(HeaderXRequestID))
+	}
+	if !strings.Contains(logs.String(), "request_id=abc-123") {
+		t.Fatalf("the access log should carry the ID: %q", logs.String())
+	}
+
+	// 没有或者过长的 ID 换成新生成的
+	for _, id := range []string{"", strings.Repeat("x", maxRequestIDLen+1)} {
+		req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+		req.Header.Set(HeaderXRequestID, id)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if got := w.Header().Get(HeaderXRequestID); len(got) != 32 || got != seen {
+			t.Fatalf("expected a generated ID, got %q (handler saw %q)", got, seen)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package gee
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+const noWritten = -1
+
+// ResponseWriter is the writer handlers get as c.Writer. On top of
+// http.ResponseWriter it tracks the status and body size (for logging,
+// metrics and compression), ignores repeated WriteHeader calls and passes
+// Flush, Hijack and Push through to the connection.
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Flusher
+	http.Hijacker
+	http.Pusher
+
+	// Status returns the status code of the response.
+	Status() int
+	// Size returns the number of body bytes written, -1 before anything
+	// was written.
+	Size() int
+	// Written reports whether the header has been sent.
+	Written() bool
+	// WriteHeaderNow sends the header if it has not been sent yet.
+	WriteHeaderNow()
+}
+
+// responseWriter 推迟发送响应头：WriteHeader 只记录状态码，
+// 第一次写 body、Flush 或请求处理结束时才真正发送
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+	// beforeWrite 在响应头真正发出前依次执行，可以修改响应头
+	beforeWrite []func()
+}
+
+var _ ResponseWriter = (*responseWriter)(nil)
+
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	return &responseWriter{ResponseWriter: w, status: http.StatusOK, size: noWritten}
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	// 响应头已经发出，再次调用没有意义，直接忽略
+	if code > 0 && !w.Written() {
+		w.status = code
+	}
+}
+
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		for _, fn := range w.beforeWrite {
+			fn()
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeaderNow()
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+	return w.size != noWritten
+}
+
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("gee: the ResponseWriter does not implement http.Hijacker")
+	}
+	// 连接交给调用者后，gee 不再写响应头
+	if w.size == noWritten {
+		w.size = 0
+	}
+	return h.Hijack()
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
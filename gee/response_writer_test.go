@@ -0,0 +1,65 @@
+package gee
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := newResponseWriter(rec)
+	if w.Written() || w.Size() != -1 || w.Status() != http.StatusOK {
+		t.Fatalf("new writer should be unwritten with status 200")
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte("hello"))
+	w.WriteHeader(http.StatusInternalServerError)
+	if w.Status() != http.StatusCreated || rec.Code != http.StatusCreated {
+		t.Fatalf("WriteHeader after the body should be ignored, got %d", w.Status())
+	}
+	if w.Size() != 5 || !w.Written() {
+		t.Fatalf("writer should count 5 bytes, got %d", w.Size())
+	}
+
+	w = newResponseWriter(httptest.NewRecorder())
+	if err := w.Push("/app.css", nil); err != http.ErrNotSupported {
+		t.Fatalf("Push should report http.ErrNotSupported, got %v", err)
+	}
+}
+
+func TestContextPushUnsupported(t *testing.T) {
+	r := New()
+	r.GET("/", func(c *Context) {
+		if err := c.Push("/app.css", nil); err != nil {
+			t.Errorf("Push should be a no-op without HTTP/2, got %v", err)
+		}
+		c.String(http.StatusOK, "ok")
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+}
+
+func TestTrailers(t *testing.T) {
+	r := New()
+	r.Use(Timeout(time.Second))
+	r.GET("/stream", func(c *Context) {
+		c.SetTrailer("X-Checksum")
+		c.String(http.StatusOK, "chunk")
+		c.WriteTrailer("X-Checksum", "abc")
+	})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+	resp := w.Result()
+	if resp.Header.Get("Trailer") != "X-Checksum" {
+		t.Fatalf("Trailer header = %q", resp.Header.Get("Trailer"))
+	}
+	if got := resp.Trailer.Get("X-Checksum"); got != "abc" {
+		t.Fatalf("trailer X-Checksum = %q", got)
+	}
+}
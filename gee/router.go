@@ -6,14 +6,25 @@ import (
 )
 
 type router struct {
-	roots    map[string]*node
-	handlers map[string]HandlerFunc
+	roots  map[string]*node
+	routes map[string]*Route
+}
+
+// Route is a registered route. The route registration methods return it
+// so metadata such as documentation can be attached.
+type Route struct {
+	method  string
+	pattern string
+	// handlers 是注册路由时确定的处理链：所属分组及其上级分组的中间件，最后是处理函数
+	handlers []HandlerFunc
+	group    *RouterGroup
+	doc      *RouteDoc
 }
 
 func newRouter() *router {
 	return &router{
-		roots:    make(map[string]*node),
-		handlers: make(map[string]HandlerFunc)}
+		roots:  make(map[string]*node),
+		routes: make(map[string]*Route)}
 }
 
 // Only one * is allowed
@@ -32,7 +43,7 @@ func parsePattern(pattern string) []string {
 	return parts
 }
 
-func (r *router) addRoute(method string, pattern string, handler HandlerFunc) {
+func (r *router) addRoute(method string, pattern string, rt *Route) {
 	parts := parsePattern(pattern)
 
 	key := method + "-" + pattern
@@ -40,7 +51,7 @@ func (r *router) addRoute(method string, pattern string, handler HandlerFunc) {
 		r.roots[method] = &node{}
 	}
 	r.roots[method].insert(pattern, parts, 0)
-	r.handlers[key] = handler
+	r.routes[key] = rt
 }
 
 func (r *router) getRoute(method string, path string) (*node, map[string]string) {
@@ -72,18 +83,41 @@ func (r *router) getRoute(method string, path string) (*node, map[string]string)
 }
 
 func (r *router) handle(c *Context) {
+	if c.engine != nil && c.engine.methodOverride && !r.overrideMethod(c) {
+		c.rootHandlers(bodyTooLargeHandler)
+		c.Next()
+		c.Writer.WriteHeaderNow()
+		return
+	}
 	// 返回的 n 是找到的路由节点，params 是路径中提取的参数。f
 	n, params := r.getRoute(c.Method, c.Path)
 
 	// 如果找到匹配的路由节点 n，则创建一个唯一标识该路由的 key（由请求方法和路由模式构成）。
 	if n != nil {
 		key := c.Method + "-" + n.pattern
+		rt := r.routes[key]
 		c.Params = params
-		c.handlers = append(c.handlers, r.handlers[key])
+		c.fullPath = n.pattern
+		c.handlers = rt.handlers
+		c.group = rt.group
+		if !c.limitBody() {
+			c.rootHandlers(bodyTooLargeHandler)
+		}
 	} else {
-		c.handlers = append(c.handlers, func(c *Context) {
+		c.rootHandlers(func(c *Context) {
 			c.String(http.StatusNotFound, "404 NOT FOUND: %s\n", c.Path)
 		})
 	}
 	c.Next()
+	// 只设置了状态码、没有写 body 的响应在这里发出
+	c.Writer.WriteHeaderNow()
+}
+
+// rootHandlers 让请求只经过全局中间件再交给 handler，用于 404、413 这类没有进入路由的响应
+func (c *Context) rootHandlers(handler HandlerFunc) {
+	root := c.engine.RouterGroup
+	c.handlers = make([]HandlerFunc, 0, len(root.middlewares)+1)
+	c.handlers = append(c.handlers, root.middlewares...)
+	c.handlers = append(c.handlers, handler)
+	c.group = root
 }
@@ -2,7 +2,10 @@ package gee
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -44,3 +47,141 @@ func TestGetRoute(t *testing.T) {
 	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps["name"])
 
 }
+
+func TestHandleContext(t *testing.T) {
+	r := New()
+	r.GET("/old/:name", func(c *Context) {
+		c.Set("forwarded", true)
+		c.Req.URL.Path = "/new/" + c.Param("name")
+		r.HandleContext(c)
+	})
+	r.GET("/new/:name", func(c *Context) {
+		c.String(http.StatusOK, "%s %v", c.Param("name"), c.Keys["forwarded"])
+	})
+	r.GET("/loop", func(c *Context) {
+		r.HandleContext(c)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/old/gee", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "gee true" {
+		t.Fatalf("forward: %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/loop", nil))
+	if w.Code != http.StatusLoopDetected {
+		t.Fatalf("loop: status = %d", w.Code)
+	}
+}
+
+func TestOnFinishAfterPanic(t *testing.T) {
+	r := New()
+	finished := false
+	r.GET("/panic", func(c *Context) {
+		c.OnFinish(func() { finished = true })
+		panic("boom")
+	})
+
+	func() {
+		// 没有 Recovery 时 panic 交给 net/http，OnFinish 也要先执行
+		defer func() { recover() }()
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/panic", nil))
+	}()
+	if !finished {
+		t.Fatalf("OnFinish hooks should run when a handler panics")
+	}
+}
+
+func TestGroupMiddlewares(t *testing.T) {
+	r := New()
+	var trail []string
+	mark := func(name string) HandlerFunc {
+		return func(c *Context) {
+			trail = append(trail, name)
+			c.Next()
+		}
+	}
+	r.Use(mark("global"))
+	api := r.Group("/api")
+	api.Use(mark("api"))
+	v1 := api.Group("/v1")
+	v1.Use(mark("v1"))
+	v1.GET("/users/:id", func(c *Context) {})
+	r.Group("/apiv2").GET("/users", func(c *Context) {})
+
+	cases := []struct {
+		path string
+		want []string
+	}{
+		{"/api/v1/users/1", []string{"global", "api", "v1"}},
+		{"/apiv2/users", []string{"global"}},
+		{"/missing", []string{"global"}},
+	}
+	for _, tc := range cases {
+		trail = nil
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if !reflect.DeepEqual(trail, tc.want) {
+			t.Errorf("%s ran %v, want %v", tc.path, trail, tc.want)
+		}
+	}
+}
+
+func TestMethodOverride(t *testing.T) {
+	r := New()
+	r.SetMethodOverride(true)
+	api := r.Group("/api")
+	api.MaxRequestBodySize(32)
+	for _, m := range []string{"POST", "PUT", "DELETE"} {
+		method := m
+		api.addRoute(method, "/item", func(c *Context) {
+			c.String(http.StatusOK, method)
+		})
+	}
+
+	form := "application/x-www-form-urlencoded"
+	cases := []struct {
+		name   string
+		target string
+		header map[string]string
+		body   string
+		want   string
+		code   int
+	}{
+		{"header", "/api/item", map[string]string{"X-HTTP-Method-Override": "delete"}, "", "DELETE", http.StatusOK},
+		{"form", "/api/item", map[string]string{"Content-Type": form}, "_method=PUT", "PUT", http.StatusOK},
+		{"query ignored", "/api/item?_method=DELETE", map[string]string{"Content-Type": form}, "a=1", "POST", http.StatusOK},
+		{"not a form", "/api/item", map[string]string{"Content-Type": "text/plain"}, "_method=PUT", "POST", http.StatusOK},
+		{"only to PUT/PATCH/DELETE", "/api/item", map[string]string{"Content-Type": form}, "_method=GET", "POST", http.StatusOK},
+		{"body limit", "/api/item", map[string]string{"Content-Type": form}, "_method=PUT&pad=" + strings.Repeat("x", 40), "", http.StatusRequestEntityTooLarge},
+		{"expect continue", "/api/item", map[string]string{"Content-Type": form, "Expect": "100-continue"}, "_method=PUT", "POST", http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.body))
+		for k, v := range tc.header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.code || (tc.code == http.StatusOK && w.Body.String() != tc.want) {
+			t.Errorf("%s: got %d %q, want %d %q", tc.name, w.Code, w.Body.String(), tc.code, tc.want)
+		}
+	}
+}
+
+func TestMethodOverrideChunkedBody(t *testing.T) {
+	r := New()
+	r.SetMethodOverride(true)
+	r.MaxRequestBodySize(16)
+	r.PUT("/item", func(c *Context) {
+		c.String(http.StatusOK, "PUT")
+	})
+	req := httptest.NewRequest(http.MethodPost, "/item", strings.NewReader("_method=PUT&pad="+strings.Repeat("x", 40)))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.ContentLength = -1
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("chunked form over the limit: status = %d, want 413", w.Code)
+	}
+}
@@ -0,0 +1,289 @@
+package gee
+
+import (
+	"html/template"
+	"net/url"
+	"reflect"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLSanitizer cleans user-supplied HTML fragments against an allow-list
+// of elements and attributes. Everything else is removed: unknown tags are
+// dropped but their text is kept, while script, style and similar elements
+// are dropped with their content. URL attributes only keep http, https,
+// mailto and relative URLs.
+type HTMLSanitizer struct {
+	// allowed 记录允许的元素以及每个元素允许的属性
+	allowed map[string]map[string]bool
+}
+
+// NewHTMLSanitizer returns a sanitizer that allows nothing; add elements
+// with AllowElements and AllowAttrs.
+func NewHTMLSanitizer() *HTMLSanitizer {
+	return &HTMLSanitizer{allowed: make(map[string]map[string]bool)}
+}
+
+// UGCSanitizer returns a sanitizer for user generated content such as
+// comments: text formatting, lists, quotes, code, links and images.
+func UGCSanitizer() *HTMLSanitizer {
+	return NewHTMLSanitizer().
+		AllowElements("p", "br", "b", "i", "em", "strong", "u", "s", "sub", "sup",
+			"ul", "ol", "li", "blockquote", "code", "pre", "hr",
+			"h1", "h2", "h3", "h4", "h5", "h6").
+		AllowAttrs("a", "href", "title").
+		AllowAttrs("img", "src", "alt", "title", "width", "height")
+}
+
+// AllowElements allows elements without attributes.
+func (s *HTMLSanitizer) AllowElements(elements ...string) *HTMLSanitizer {
+	for _, e := range elements {
+		if s.allowed[e] == nil {
+			s.allowed[e] = make(map[string]bool)
+		}
+	}
+	return s
+}
+
+// AllowAttrs allows element with the given attributes.
+func (s *HTMLSanitizer) AllowAttrs(element string, attrs ...string) *HTMLSanitizer {
+	s.AllowElements(element)
+	for _, a := range attrs {
+		s.allowed[element][a] = true
+	}
+	return s
+}
+
+// dropContent 中的元素连同内容一起删除
+var dropContent = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true,
+	"embed": true, "noscript": true, "template": true, "textarea": true,
+}
+
+var voidElements = map[string]bool{
+	"br": true, "hr": true, "img": true, "wbr": true,
+}
+
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+// Sanitize returns fragment with everything not allowed removed. Tags left
+// open are closed at the end, so a fragment cannot break the page layout.
+func (s *HTMLSanitizer) Sanitize(fragment string) string {
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	var b strings.Builder
+	var open []string
+	skip, skipTag := 0, ""
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				b.WriteString("</" + open[i] + ">")
+			}
+			return b.String()
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(string(z.Text())))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if skip > 0 {
+				if tok.Data == skipTag && tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if dropContent[tok.Data] {
+				if tt == html.StartTagToken {
+					skip, skipTag = 1, tok.Data
+				}
+				continue
+			}
+			attrs, ok := s.allowed[tok.Data]
+			if !ok {
+				continue
+			}
+			b.WriteString("<" + tok.Data)
+			for _, a := range tok.Attr {
+				if !attrs[a.Key] || (urlAttrs[a.Key] && !safeURL(a.Val)) {
+					continue
+				}
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+				if tok.Data == "a" && a.Key == "href" {
+					b.WriteString(` rel="nofollow noopener"`)
+				}
+			}
+			b.WriteString(">")
+			if voidElements[tok.Data] {
+				continue
+			}
+			if tt == html.StartTagToken {
+				open = append(open, tok.Data)
+			} else {
+				// <b/> 之类自闭合的非空元素当场关闭，不然后面的内容都会落在里面
+				b.WriteString("</" + tok.Data + ">")
+			}
+		case html.EndTagToken:
+			tok := z.Token()
+			if skip > 0 {
+				if tok.Data == skipTag {
+					skip--
+				}
+				continue
+			}
+			// 只关闭确实打开过的标签，多余的结束标签直接丢掉
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == tok.Data {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j] + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+	}
+}
+
+// safeURL 只允许 http、https、mailto 和相对地址，拦截 javascript: 之类的协议
+func safeURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// StripTags returns the text of fragment with all markup removed, escaped
+// for HTML.
+func StripTags(fragment string) string {
+	return NewHTMLSanitizer().Sanitize(fragment)
+}
+
+// FuncMap returns template functions using s: "sanitize" renders a user
+// fragment as cleaned HTML, "stripTags" keeps only its text. Merge them
+// into the map passed to SetFuncMap.
+func (s *HTMLSanitizer) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"sanitize": func(fragment string) template.HTML {
+			return template.HTML(s.Sanitize(fragment))
+		},
+		"stripTags": StripTags,
+	}
+}
+
+// SetHTMLSanitizer sets the sanitizer used by Context.SecureHTML; the
+// default is UGCSanitizer.
+func (engine *Engine) SetHTMLSanitizer(s *HTMLSanitizer) {
+	engine.htmlSanitizer = s
+}
+
+// SecureHTML renders the template like HTML, but first sanitizes every
+// template.HTML value in data, including behind pointers and inside maps,
+// slices, arrays and struct fields, embedded ones too. Values nested more
+// than 32 levels deep are dropped rather than rendered unchecked. Handlers
+// passing user content through the template.HTML escape hatch can use it
+// to stay safe from XSS.
+func (c *Context) SecureHTML(code int, name string, data interface{}) {
+	s := c.engine.htmlSanitizer
+	if s == nil {
+		s = UGCSanitizer()
+	}
+	if data != nil {
+		data = sanitizeValue(s, reflect.ValueOf(data), 0).Interface()
+	}
+	c.HTML(code, name, data)
+}
+
+var htmlType = reflect.TypeOf(template.HTML(""))
+
+// maxSanitizeDepth 防止指针成环的数据无限递归
+const maxSanitizeDepth = 32
+
+// sanitizeValue 返回清理过 template.HTML 的副本，不修改调用者的数据。
+// 超过 maxSanitizeDepth 的部分换成零值，宁可不显示也不能原样输出
+func sanitizeValue(s *HTMLSanitizer, v reflect.Value, depth int) reflect.Value {
+	if v.Type() == htmlType {
+		return reflect.ValueOf(template.HTML(s.Sanitize(v.String())))
+	}
+	if depth > maxSanitizeDepth {
+		return reflect.Zero(v.Type())
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(sanitizeValue(s, v.Elem(), depth+1))
+		return out
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Elem().Type())
+		out.Elem().Set(convertTo(sanitizeValue(s, v.Elem(), depth+1), v.Elem().Type()))
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), convertTo(sanitizeValue(s, iter.Value(), depth+1), v.Type().Elem()))
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(convertTo(sanitizeValue(s, v.Index(i), depth+1), v.Type().Elem()))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(convertTo(sanitizeValue(s, v.Index(i), depth+1), v.Type().Elem()))
+		}
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			// 从副本里取字段，嵌入的未导出结构体换成可写的视图后也能继续递归
+			field := out.Field(i)
+			if !field.CanSet() {
+				// 其他未导出的字段模板访问不到，不用处理；
+				// 嵌入的未导出结构体的导出字段会被提升，模板能访问到，
+				// 它在我们自己的副本 out 里，可以绕过只读标记写回
+				if !f.Anonymous {
+					continue
+				}
+				field = reflect.NewAt(f.Type, unsafe.Pointer(field.UnsafeAddr())).Elem()
+			}
+			field.Set(convertTo(sanitizeValue(s, field, depth+1), f.Type))
+		}
+		return out
+	}
+	return v
+}
+
+// convertTo 把 sanitizeValue 的结果放回原来的类型，比如 interface{} 元素
+func convertTo(v reflect.Value, t reflect.Type) reflect.Value {
+	if v.Type() == t {
+		return v
+	}
+	out := reflect.New(t).Elem()
+	out.Set(v)
+	return out
+}
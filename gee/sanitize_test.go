@@ -0,0 +1,87 @@
+package gee
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHTMLSanitizer(t *testing.T) {
+	s := UGCSanitizer()
+	cases := []struct {
+		in, want string
+	}{
+		{`<b>bold</b> text`, `<b>bold</b> text`},
+		{`<script>alert(1)</script>hi`, `hi`},
+		{`<p onclick="x()">para`, `<p>para</p>`},
+		{`<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{`<a href="https://example.com" target="_blank">x</a>`, `<a href="https://example.com" rel="nofollow noopener">x</a>`},
+		{`<div><i>a</div></i>`, `<i>a</i>`},
+		{`1 < 2 & 3`, `1 &lt; 2 &amp; 3`},
+		{`<b/>text<p/>`, `<b></b>text<p></p>`},
+		{`a<br/>b`, `a<br>b`},
+	}
+	for _, tc := range cases {
+		if got := s.Sanitize(tc.in); got != tc.want {
+			t.Errorf("Sanitize(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+	if got := StripTags(`<b>x</b><img src=a>`); got != "x" {
+		t.Errorf("StripTags = %q", got)
+	}
+}
+
+func TestSecureHTML(t *testing.T) {
+	r := New()
+	r.htmlTemplates = template.Must(template.New("comment").Parse(`{{.Body}}`))
+	type comment struct{ Body template.HTML }
+	r.GET("/", func(c *Context) {
+		c.SecureHTML(http.StatusOK, "comment", comment{Body: `<em>hi</em><script>x</script>`})
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Body.String() != "<em>hi</em>" {
+		t.Fatalf("body = %q", w.Body.String())
+	}
+}
+
+type sanitizeInner struct{ Body template.HTML }
+
+type sanitizeOuter struct {
+	sanitizeInner
+	Ptr   *template.HTML
+	Array [1]template.HTML
+}
+
+// sanitizeNode 可以嵌套任意深度
+type sanitizeNode struct {
+	Body template.HTML
+	Next *sanitizeNode
+}
+
+func TestSanitizeValue(t *testing.T) {
+	s := UGCSanitizer()
+	bad := template.HTML(`<em>hi</em><script>x</script>`)
+	in := sanitizeOuter{sanitizeInner: sanitizeInner{Body: bad}, Ptr: &bad, Array: [1]template.HTML{bad}}
+
+	out := sanitizeValue(s, reflect.ValueOf(in), 0).Interface().(sanitizeOuter)
+	if out.Body != "<em>hi</em>" || *out.Ptr != "<em>hi</em>" || out.Array[0] != "<em>hi</em>" {
+		t.Fatalf("embedded, pointer and array values should be sanitized, got %+v %q", out, *out.Ptr)
+	}
+	if in.Body != bad || *in.Ptr != bad {
+		t.Fatalf("the caller's data should not be modified")
+	}
+
+	// 超过深度限制的部分不能原样保留
+	head := &sanitizeNode{Body: bad}
+	head.Next = head
+	node := sanitizeValue(s, reflect.ValueOf(head), 0).Interface().(*sanitizeNode)
+	for ; node != nil; node = node.Next {
+		if node.Body != "" && node.Body != "<em>hi</em>" {
+			t.Fatalf("values past the depth limit should be dropped, got %q", node.Body)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+package gee
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// serverSet 记录 engine 启动的所有 http.Server，Shutdown 时统一关闭
+type serverSet struct {
+	mu      sync.Mutex
+	servers []*http.Server
+	// closers 是不能优雅关闭的监听，比如 HTTP/3
+	closers []io.Closer
+	// closed 在 Shutdown 之后为 true，之后登记的服务立即关闭
+	closed bool
+}
+
+// add 登记 srv；Shutdown 已经开始时直接关闭它，比如信号在 OnStart 钩子执行期间到达，
+// 这样 Serve 会马上返回 http.ErrServerClosed，而不是在没人关闭的情况下一直运行
+func (s *serverSet) add(srv *http.Server) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		srv.Close()
+		return
+	}
+	s.servers = append(s.servers, srv)
+}
+
+func (s *serverSet) addCloser(c io.Closer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		c.Close()
+		return
+	}
+	s.closers = append(s.closers, c)
+}
+
+// ServerConfig holds the http.Server timeouts and limits used by Run and
+// the other Run* methods. Zero values mean no limit, as in net/http.
+type ServerConfig struct {
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+}
+
+// DefaultServerConfig bounds how long a client may take to send headers
+// and keep an idle connection, so slow clients cannot hold connections
+// forever. Read and write timeouts stay off because they would cut long
+// uploads and streaming responses.
+var DefaultServerConfig = ServerConfig{
+	ReadHeaderTimeout: 10 * time.Second,
+	IdleTimeout:       120 * time.Second,
+	MaxHeaderBytes:    http.DefaultMaxHeaderBytes,
+}
+
+// SetServerConfig replaces DefaultServerConfig for servers started
+// afterwards.
+func (engine *Engine) SetServerConfig(conf ServerConfig) {
+	engine.serverConfig = &conf
+}
+
+func (engine *Engine) newServer(addr string) *http.Server {
+	conf := DefaultServerConfig
+	if engine.serverConfig != nil {
+		conf = *engine.serverConfig
+	}
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           engine,
+		ReadTimeout:       conf.ReadTimeout,
+		ReadHeaderTimeout: conf.ReadHeaderTimeout,
+		WriteTimeout:      conf.WriteTimeout,
+		IdleTimeout:       conf.IdleTimeout,
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+	}
+	engine.servers.add(srv)
+	return srv
+}
+
+// RunListener serves on an existing listener, e.g. one handed over by
+// systemd socket activation, until Shutdown.
+func (engine *Engine) RunListener(listener net.Listener) error {
+	if err := engine.start(); err != nil {
+		listener.Close()
+		return err
+	}
+	srv := engine.newServer(listener.Addr().String())
+	srv.Handler = engine.cleartextHandler()
+	return serveErr(srv.Serve(listener))
+}
+
+// RunUnix serves on the unix domain socket at path with the given file
+// permissions, e.g. as an nginx upstream. A stale socket file left by a
+// previous run is removed first.
+func (engine *Engine) RunUnix(path string, perms os.FileMode) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+	if err := os.Chmod(path, perms); err != nil {
+		listener.Close()
+		return err
+	}
+	return engine.RunListener(listener)
+}
+
+// RunMulti serves on several addresses at once, e.g. an internal and an
+// external interface. Addresses starting with "unix:" are unix domain
+// sockets. If any server fails the others are shut down as well; Shutdown
+// stops all of them.
+func (engine *Engine) RunMulti(addrs ...string) error {
+	listeners := make([]net.Listener, 0, len(addrs))
+	for _, addr := range addrs {
+		network := "tcp"
+		if strings.HasPrefix(addr, "unix:") {
+			network, addr = "unix", strings.TrimPrefix(addr, "unix:")
+		}
+		listener, err := net.Listen(network, addr)
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return err
+		}
+		listeners = append(listeners, listener)
+	}
+	return engine.RunListeners(listeners...)
+}
+
+// RunListeners serves on all listeners as a group, like RunMulti. TLS is
+// served by wrapping a listener with tls.NewListener.
+func (engine *Engine) RunListeners(listeners ...net.Listener) error {
+	serves := make([]func() error, 0, len(listeners))
+	for _, listener := range listeners {
+		l := listener
+		serves = append(serves, func() error { return engine.RunListener(l) })
+	}
+	return engine.serveGroup(serves...)
+}
+
+// serveGroup 并发运行一组监听，等它们全部退出后返回第一个错误
+func (engine *Engine) serveGroup(serves ...func() error) error {
+	errChan := make(chan error, len(serves))
+	for _, serve := range serves {
+		go func(serve func() error) {
+			errChan <- serveErr(serve())
+		}(serve)
+	}
+
+	var firstErr error
+	for range serves {
+		err := <-errChan
+		if err != nil && firstErr == nil {
+			firstErr = err
+			// 一个监听失败就关闭整组，避免只剩部分接口在服务
+			engine.Shutdown(context.Background())
+		}
+	}
+	return firstErr
+}
+
+// RunTLS serves HTTPS on addr with the given certificate until Shutdown.
+func (engine *Engine) RunTLS(addr string, certFile string, keyFile string) error {
+	if err := engine.start(); err != nil {
+		return err
+	}
+	return serveErr(engine.newServer(addr).ListenAndServeTLS(certFile, keyFile))
+}
+
+// RunTLSConfig serves HTTPS on addr using config, which sets the minimum
+// version, cipher suites, client authentication and must provide
+// Certificates or GetCertificate. TLS 1.2 is the default minimum version.
+func (engine *Engine) RunTLSConfig(addr string, config *tls.Config) error {
+	if err := engine.start(); err != nil {
+		return err
+	}
+	srv := engine.newServer(addr)
+	srv.TLSConfig = secureTLSConfig(config)
+	return serveErr(srv.ListenAndServeTLS("", ""))
+}
+
+// secureTLSConfig 复制一份配置，避免修改调用者的对象，并且不允许低于 TLS 1.2
+func secureTLSConfig(config *tls.Config) *tls.Config {
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config = config.Clone()
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+	return config
+}
+
+// Shutdown stops accepting new connections and waits for in-flight requests
+// to finish, or for ctx to be done. It then stops the background tasks
+// started with Go and Every and runs the OnShutdown hooks. Run then returns
+// nil, and a Run still in its OnStart hooks returns nil without serving.
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	engine.servers.mu.Lock()
+	servers, closers := engine.servers.servers, engine.servers.closers
+	engine.servers.servers, engine.servers.closers = nil, nil
+	engine.servers.closed = true
+	engine.servers.mu.Unlock()
+
+	var firstErr error
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, c := range closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := engine.stopTasks(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	if err := engine.stop(ctx); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// RunWithSignals runs the engine on addr until SIGINT or SIGTERM, then shuts
+// down gracefully, giving in-flight requests up to timeout to finish.
+func (engine *Engine) RunWithSignals(addr string, timeout time.Duration) error {
+	return engine.runWithSignals(func() error { return engine.Run(addr) }, timeout)
+}
+
+func (engine *Engine) runWithSignals(run func() error, timeout time.Duration) error {
+	// 先订阅信号再启动，OnStart 钩子执行期间到达的信号也能收到
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- run()
+	}()
+
+	select {
+	case err := <-errChan:
+		// 服务没能启动，比如端口被占用
+		return err
+	case sig := <-quit:
+		engine.logger.Log(LevelInfo, "shutting down", "signal", sig.String())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := engine.Shutdown(ctx); err != nil {
+		return err
+	}
+	return <-errChan
+}
+
+// serveErr 把 Shutdown 导致的 http.ErrServerClosed 视为正常退出
+func serveErr(err error) error {
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,412 @@
+package gee
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunUnix(t *testing.T) {
+	r := New()
+	r.GET("/ping", func(c *Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	path := filepath.Join(t.TempDir(), "gee.sock")
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunUnix(path, 0600) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		if resp, err = client.Get("http://unix/ping"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "pong" {
+		t.Fatalf("body = %q", body)
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("RunUnix returned %v", err)
+	}
+}
+
+func TestRunMulti(t *testing.T) {
+	r := New()
+	r.GET("/ping", func(c *Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	var addrs []string
+	for i := 0; i < 2; i++ {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addrs = append(addrs, l.Addr().String())
+		l.Close()
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunMulti(addrs...) }()
+
+	for _, addr := range addrs {
+		var resp *http.Response
+		var err error
+		for i := 0; i < 50; i++ {
+			if resp, err = http.Get("http://" + addr + "/ping"); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status = %d", addr, resp.StatusCode)
+		}
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("RunMulti returned %v", err)
+	}
+}
+
+func TestServeGroupClosesSiblings(t *testing.T) {
+	r := New()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := r.newServer(l.Addr().String())
+	failed := errors.New("listen failed")
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- r.serveGroup(func() error {
+			return srv.Serve(l)
+		}, func() error {
+			return failed
+		})
+	}()
+
+	// 另一个监听失败后，正常的监听也要被关闭，serveGroup 才会返回
+	select {
+	case err := <-errChan:
+		if err != failed {
+			t.Fatalf("serveGroup returned %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a failed listener should shut down its siblings")
+	}
+}
+
+// blockingHTTP3 一直运行到 Close
+type blockingHTTP3 struct {
+	closed chan struct{}
+}
+
+func (s *blockingHTTP3) ListenAndServeTLS(certFile string, keyFile string) error {
+	<-s.closed
+	return http.ErrServerClosed
+}
+
+func (s *blockingHTTP3) Close() error {
+	close(s.closed)
+	return nil
+}
+
+func TestRunHTTP3ClosesQUIC(t *testing.T) {
+	r := New()
+	quic := &blockingHTTP3{closed: make(chan struct{})}
+	r.SetHTTP3Server(func(addr string, h http.Handler) HTTP3Server { return quic })
+
+	// 证书不存在，TCP 监听启动失败，QUIC 监听也要跟着关闭
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunHTTP3("127.0.0.1:0", "missing.crt", "missing.key") }()
+	select {
+	case err := <-errChan:
+		if err == nil {
+			t.Fatal("RunHTTP3 should report the TCP listener error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RunHTTP3 should return when the TCP listener fails")
+	}
+	select {
+	case <-quic.closed:
+	default:
+		t.Fatal("the QUIC listener should be closed when the TCP listener fails")
+	}
+}
+
+func TestShutdownWaitsForRequests(t *testing.T) {
+	r := New()
+	started, release := make(chan struct{}), make(chan struct{})
+	r.GET("/slow", func(c *Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunListener(l) }()
+	bodyChan := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + l.Addr().String() + "/slow")
+		if err != nil {
+			bodyChan <- err.Error()
+			return
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		bodyChan <- string(body)
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- r.Shutdown(context.Background()) }()
+	select {
+	case <-shutdown:
+		t.Fatal("Shutdown should wait for in-flight requests")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	if body := <-bodyChan; body != "done" {
+		t.Fatalf("in-flight request should complete, got %q", body)
+	}
+	if err := <-shutdown; err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("RunListener should return nil after Shutdown, got %v", err)
+	}
+}
+
+func TestRunWithSignals(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM cannot be sent on windows")
+	}
+	// 先自己订阅 SIGTERM，信号在 RunWithSignals 订阅之前到达也不会结束测试进程
+	guard := make(chan os.Signal, 100)
+	signal.Notify(guard, syscall.SIGTERM)
+	defer signal.Stop(guard)
+
+	r := New()
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunWithSignals("127.0.0.1:0", time.Second) }()
+	self, _ := os.FindProcess(os.Getpid())
+	for i := 0; i < 100; i++ {
+		self.Signal(syscall.SIGTERM)
+		select {
+		case err := <-errChan:
+			if err != nil {
+				t.Fatalf("RunWithSignals returned %v", err)
+			}
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	t.Fatal("RunWithSignals should return after SIGTERM")
+}
+
+func TestRunTLSConfig(t *testing.T) {
+	// 借用 httptest 的自签名证书
+	ts := httptest.NewTLSServer(nil)
+	cert := ts.TLS.Certificates[0]
+	ts.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	r := New()
+	r.GET("/ping", func(c *Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunTLSConfig(addr, config) }()
+	if config.MinVersion != 0 {
+		t.Fatal("RunTLSConfig should not modify the caller's config")
+	}
+
+	for i := 0; i < 50; i++ {
+		var conn net.Conn
+		if conn, err = net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 低于 TLS 1.2 的客户端被拒绝
+	old := &tls.Config{InsecureSkipVerify: true, MaxVersion: tls.VersionTLS11}
+	if conn, err := tls.Dial("tcp", addr, old); err == nil {
+		conn.Close()
+		t.Fatal("TLS 1.1 clients should be refused")
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	resp, err := client.Get("https://" + addr + "/ping")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.TLS == nil {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatalf("RunTLSConfig returned %v", err)
+	}
+}
+
+func TestSignalDuringStart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM cannot be sent on windows")
+	}
+	guard := make(chan os.Signal, 100)
+	signal.Notify(guard, syscall.SIGTERM)
+	defer signal.Stop(guard)
+
+	r := New()
+	starting := make(chan struct{})
+	r.OnStart(func(ctx context.Context) error {
+		close(starting)
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunWithSignals("127.0.0.1:0", time.Second) }()
+	<-starting
+	// 信号在 OnStart 钩子执行期间到达，服务登记之后也要马上关闭
+	self, _ := os.FindProcess(os.Getpid())
+	self.Signal(syscall.SIGTERM)
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Fatalf("RunWithSignals returned %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunWithSignals should return when signaled during OnStart")
+	}
+}
+
+func TestServerConfig(t *testing.T) {
+	r := New()
+	srv := r.newServer(":0")
+	if srv.ReadHeaderTimeout != DefaultServerConfig.ReadHeaderTimeout {
+		t.Fatalf("ReadHeaderTimeout = %v", srv.ReadHeaderTimeout)
+	}
+
+	r.SetServerConfig(ServerConfig{WriteTimeout: time.Minute, MaxHeaderBytes: 4096})
+	srv = r.newServer(":0")
+	if srv.WriteTimeout != time.Minute || srv.MaxHeaderBytes != 4096 || srv.ReadHeaderTimeout != 0 {
+		t.Fatalf("server = %+v", srv)
+	}
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	r := New()
+	var calls []string
+	hook := func(name string) LifecycleHook {
+		return func(ctx context.Context) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	r.OnStart(hook("start db"))
+	r.OnStart(hook("start cache"))
+	r.OnShutdown(hook("stop db"))
+	r.OnShutdown(hook("stop cache"))
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errChan := make(chan error, 1)
+	go func() { errChan <- r.RunListener(l) }()
+	for i := 0; i < 50; i++ {
+		if resp, err := http.Get("http://" + l.Addr().String() + "/"); err == nil {
+			resp.Body.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"start db", "start cache", "stop cache", "stop db"}
+	if len(calls) != len(want) {
+		t.Fatalf("calls = %v", calls)
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Fatalf("calls = %v, want %v", calls, want)
+		}
+	}
+}
+
+func TestBackgroundTasks(t *testing.T) {
+	r := New()
+	ticks := make(chan struct{}, 10)
+	r.Every(time.Millisecond, func(ctx context.Context) {
+		select {
+		case ticks <- struct{}{}:
+		default:
+		}
+	})
+	stopped := false
+	r.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		stopped = true
+	})
+	<-ticks
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !stopped {
+		t.Fatal("Shutdown should wait for background tasks")
+	}
+}
@@ -0,0 +1,311 @@
+package gee
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StaticConfig configures StaticWithConfig.
+type StaticConfig struct {
+	// MaxAge 是 Cache-Control 的 max-age；为 0 时发送 no-cache，
+	// 浏览器每次都会带上 ETag 校验，文件没变时返回 304
+	MaxAge time.Duration
+	// Fallback 是找不到文件时返回的文件，如单页应用的 index.html。
+	// 只对没有扩展名的路径生效，缺失的 .js、.css 仍然返回 404
+	Fallback string
+	// DisableListing 为 true 时没有 index.html 的目录返回 404，而不是文件列表
+	DisableListing bool
+	// ListingTemplate 用来渲染目录列表，数据是 DirListing；为空时使用 http.FileServer 的默认列表
+	ListingTemplate *template.Template
+	// Precompressed 为 true 时，如果有 app.js.br、app.js.gz 这样预先压缩好的文件，
+	// 按 Accept-Encoding 直接返回它们，br 优先
+	Precompressed bool
+}
+
+// DirListing is the data ListingTemplate renders.
+type DirListing struct {
+	// Path is the URL path of the directory, ending in a slash.
+	Path    string
+	Entries []DirEntry
+}
+
+// DirEntry is a file or subdirectory in a DirListing.
+type DirEntry struct {
+	Name    string
+	IsDir   bool
+	Size    int64
+	ModTime time.Time
+}
+
+func (group *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem, conf StaticConfig) HandlerFunc {
+	absolutePath := path.Join(group.prefix, relativePath)
+	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
+	etags := &staticETags{}
+	cacheControl := "no-cache"
+	if conf.MaxAge > 0 {
+		cacheControl = fmt.Sprintf("public, max-age=%d", int64(conf.MaxAge/time.Second))
+	}
+	return func(c *Context) {
+		file := c.Param("filepath")
+		f, err := fs.Open(file)
+		if err != nil {
+			if conf.Fallback != "" && path.Ext(file) == "" {
+				serveFallback(c, fs, conf.Fallback)
+				return
+			}
+			c.Status(http.StatusNotFound)
+			return
+		}
+		fi, err := f.Stat()
+		if err == nil && fi.IsDir() && (conf.DisableListing || conf.ListingTemplate != nil) && !hasIndex(fs, file) {
+			defer f.Close()
+			if conf.DisableListing {
+				c.Status(http.StatusNotFound)
+				return
+			}
+			renderListing(c, f, conf.ListingTemplate)
+			return
+		}
+		if err == nil && !fi.IsDir() {
+			// http.FileServer 会根据 ETag 和 Last-Modified 处理条件请求并返回 304
+			c.SetHeader("Cache-Control", cacheControl)
+			if conf.Precompressed {
+				c.Writer.Header().Add("Vary", "Accept-Encoding")
+				if servePrecompressed(c, fs, file, etags) {
+					f.Close()
+					return
+				}
+			}
+			if etag := etags.get(file, f, fi); etag != "" {
+				c.SetHeader("ETag", etag)
+			}
+		}
+		f.Close()
+
+		// HEAD 请求由 http.FileServer 处理：只写响应头和 Content-Length，不写 body
+		fileServer.ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// staticETags 生成静态文件的 ETag。磁盘文件用大小和修改时间，不用读内容；
+// embed.FS 里的文件没有修改时间，只能对内容做哈希，结果缓存起来
+type staticETags struct {
+	hashed sync.Map
+}
+
+func (e *staticETags) get(name string, f http.File, fi os.FileInfo) string {
+	if !fi.ModTime().IsZero() {
+		return fmt.Sprintf(`W/"%x-%x"`, fi.Size(), fi.ModTime().UnixNano())
+	}
+	if etag, ok := e.hashed.Load(name); ok {
+		return etag.(string)
+	}
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	etag := fmt.Sprintf(`"%x"`, h.Sum(nil))
+	e.hashed.Store(name, etag)
+	return etag
+}
+
+// precompressedEncodings 按优先级排列
+var precompressedEncodings = []struct {
+	encoding string
+	ext      string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// servePrecompressed 返回客户端接受的预压缩文件，没有合适的文件时返回 false
+func servePrecompressed(c *Context, fs http.FileSystem, file string, etags *staticETags) bool {
+	accept := c.Req.Header.Get("Accept-Encoding")
+	for _, pc := range precompressedEncodings {
+		if !acceptsEncoding(accept, pc.encoding) {
+			continue
+		}
+		f, err := fs.Open(file + pc.ext)
+		if err != nil {
+			continue
+		}
+		fi, err := f.Stat()
+		if err != nil || fi.IsDir() {
+			f.Close()
+			continue
+		}
+		ctype := mime.TypeByExtension(path.Ext(file))
+		if ctype == "" {
+			ctype = "application/octet-stream"
+		}
+		c.SetHeader("Content-Type", ctype)
+		c.SetHeader("Content-Encoding", pc.encoding)
+		// 压缩后的内容和原文件不同，ETag 也要不同
+		if etag := etags.get(file+pc.ext, f, fi); etag != "" {
+			c.SetHeader("ETag", etag)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err == nil {
+			http.ServeContent(c.Writer, c.Req, file, fi.ModTime(), f)
+		}
+		f.Close()
+		return true
+	}
+	return false
+}
+
+// acceptsEncoding 解析 Accept-Encoding，q=0 表示明确不接受
+func acceptsEncoding(header string, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil && q == 0 {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// hasIndex 判断目录下有没有 index.html，有的话由 http.FileServer 返回它
+func hasIndex(fs http.FileSystem, dir string) bool {
+	f, err := fs.Open(path.Join("/", dir, "index.html"))
+	if err != nil {
+		return false
+	}
+	f.Close()
+	return true
+}
+
+func renderListing(c *Context, dir http.File, tmpl *template.Template) {
+	// 和 http.FileServer 一样，目录地址统一以 / 结尾，页面里的相对链接才正确
+	if !strings.HasSuffix(c.Req.URL.Path, "/") {
+		target := c.Req.URL.Path + "/"
+		if c.Req.URL.RawQuery != "" {
+			target += "?" + c.Req.URL.RawQuery
+		}
+		http.Redirect(c.Writer, c.Req, target, http.StatusMovedPermanently)
+		return
+	}
+	infos, err := dir.Readdir(-1)
+	if err != nil {
+		c.Fail(http.StatusInternalServerError, "cannot read directory")
+		return
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	listing := DirListing{Path: c.Req.URL.Path, Entries: make([]DirEntry, 0, len(infos))}
+	for _, fi := range infos {
+		listing.Entries = append(listing.Entries, DirEntry{
+			Name:    fi.Name(),
+			IsDir:   fi.IsDir(),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+		})
+	}
+	c.SetHeader("Content-Type", "text/html; charset=utf-8")
+	c.Status(http.StatusOK)
+	if err := tmpl.Execute(c.Writer, listing); err != nil {
+		c.logger().Log(LevelError, "directory listing failed", "error", err.Error(), "path", c.Path)
+	}
+}
+
+// serveFallback 返回单页应用的入口页面，前端路由再根据 URL 渲染。
+// 入口页面引用的资源文件名通常带哈希，入口本身不能长期缓存
+func serveFallback(c *Context, fs http.FileSystem, name string) {
+	f, err := fs.Open(name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	c.SetHeader("Cache-Control", "no-cache")
+	http.ServeContent(c.Writer, c.Req, fi.Name(), fi.ModTime(), f)
+}
+
+// staticOptionsHandler answers CORS preflight and CDN probes for static routes.
+func staticOptionsHandler(c *Context) {
+	c.SetHeader("Allow", "GET, HEAD, OPTIONS")
+	c.Status(http.StatusNoContent)
+}
+
+func (group *RouterGroup) Static(relativePath string, root string) {
+	group.StaticWithConfig(relativePath, root, StaticConfig{})
+}
+
+// StaticWithConfig is Static with cache settings, e.g. a long MaxAge for
+// fingerprinted bundles.
+func (group *RouterGroup) StaticWithConfig(relativePath string, root string, conf StaticConfig) {
+	group.StaticFSWithConfig(relativePath, http.Dir(root), conf)
+}
+
+// StaticSPA serves a single-page application built into root: existing
+// files are served as usual, and any other path without a file extension
+// gets root/index.html, so client-side routes such as /users/1 work on
+// reload.
+func (group *RouterGroup) StaticSPA(relativePath string, root string) {
+	group.StaticWithConfig(relativePath, root, StaticConfig{Fallback: "index.html"})
+}
+
+// StaticFile registers GET and HEAD routes serving one file, e.g.
+// r.StaticFile("/robots.txt", "./static/robots.txt").
+func (group *RouterGroup) StaticFile(relativePath string, filepath string) {
+	if strings.ContainsAny(relativePath, ":*") {
+		panic("gee: URL parameters can not be used when serving a static file")
+	}
+	handler := func(c *Context) {
+		c.SetHeader("Cache-Control", "no-cache")
+		http.ServeFile(c.Writer, c.Req, filepath)
+	}
+	group.GET(relativePath, handler)
+	group.HEAD(relativePath, handler)
+	group.OPTIONS(relativePath, staticOptionsHandler)
+}
+
+// StaticFS serves files from fsys under relativePath.
+func (group *RouterGroup) StaticFS(relativePath string, fsys http.FileSystem) {
+	group.StaticFSWithConfig(relativePath, fsys, StaticConfig{})
+}
+
+// StaticIOFS serves files from an fs.FS such as an embed.FS, so assets
+// can ship inside the binary:
+//
+//	//go:embed static
+//	var assets embed.FS
+//
+//	sub, _ := fs.Sub(assets, "static")
+//	r.StaticIOFS("/assets", sub)
+func (group *RouterGroup) StaticIOFS(relativePath string, fsys fs.FS) {
+	group.StaticFS(relativePath, http.FS(fsys))
+}
+
+// StaticFSWithConfig is StaticFS with cache settings.
+func (group *RouterGroup) StaticFSWithConfig(relativePath string, fsys http.FileSystem, conf StaticConfig) {
+	handler := group.createStaticHandler(relativePath, fsys, conf)
+	urlPattern := path.Join(relativePath, "/*filepath")
+	group.GET(urlPattern, handler)
+	group.HEAD(urlPattern, handler)
+	group.OPTIONS(urlPattern, staticOptionsHandler)
+}
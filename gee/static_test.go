@@ -0,0 +1,205 @@
+package gee
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestStaticCaching(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.css"), []byte("body{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.StaticWithConfig("/assets", dir, StaticConfig{MaxAge: time.Hour})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/app.css", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "body{}" {
+		t.Fatalf("GET: %d %q", w.Code, w.Body.String())
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+		t.Fatalf("Cache-Control = %q", cc)
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" || w.Header().Get("Last-Modified") == "" {
+		t.Fatal("ETag and Last-Modified should be set")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/app.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("conditional GET: %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestStaticHeadAndOptions(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("run()"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r := New()
+	r.Static("/assets", dir)
+
+	// HEAD 只返回响应头，不带 body
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/assets/app.js", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "5" {
+		t.Fatalf("HEAD: %d %q Content-Length=%q", w.Code, w.Body.String(), w.Header().Get("Content-Length"))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/assets/app.js", nil))
+	if w.Code != http.StatusNoContent || w.Header().Get("Allow") != "GET, HEAD, OPTIONS" {
+		t.Fatalf("OPTIONS: %d Allow=%q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestStaticIOFS(t *testing.T) {
+	r := New()
+	r.StaticIOFS("/assets", fstest.MapFS{
+		"js/app.js": {Data: []byte("console.log(1)")},
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/js/app.js", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "console.log(1)" {
+		t.Fatalf("GET: %d %q", w.Code, w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("files without a modification time should get a content ETag")
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/assets/js/app.js", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("conditional GET: %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/assets/missing.js", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("missing file: %d", w.Code)
+	}
+}
+
+func TestStaticSPA(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("<div id=app>"), 0644)
+	os.WriteFile(filepath.Join(dir, "app.js"), []byte("mount()"), 0644)
+	r := New()
+	r.GET("/api/ping", func(c *Context) { c.String(http.StatusOK, "pong") })
+	r.StaticSPA("/", dir)
+
+	cases := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/app.js", http.StatusOK, "mount()"},
+		{"/users/1", http.StatusOK, "<div id=app>"},
+		{"/api/ping", http.StatusOK, "pong"},
+		{"/missing.js", http.StatusNotFound, ""},
+	}
+	for _, tc := range cases {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if w.Code != tc.code || w.Body.String() != tc.body {
+			t.Errorf("%s: %d %q, want %d %q", tc.path, w.Code, w.Body.String(), tc.code, tc.body)
+		}
+	}
+}
+
+func TestStaticListing(t *testing.T) {
+	dir := t.TempDir()
+	os.Mkdir(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "docs", "b.txt"), []byte("b"), 0644)
+	os.WriteFile(filepath.Join(dir, "docs", "a.txt"), []byte("a"), 0644)
+
+	r := New()
+	r.StaticWithConfig("/files", dir, StaticConfig{
+		ListingTemplate: template.Must(template.New("dir").Parse(
+			`{{.Path}}:{{range .Entries}} {{.Name}}{{end}}`)),
+	})
+	r.StaticWithConfig("/private", dir, StaticConfig{DisableListing: true})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/docs/", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "/files/docs/: a.txt b.txt" {
+		t.Fatalf("listing: %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/files/docs", nil))
+	if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != "/files/docs/" {
+		t.Fatalf("redirect: %d %q", w.Code, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/private/docs/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("disabled listing: %d", w.Code)
+	}
+}
+
+func TestStaticFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "robots.txt")
+	os.WriteFile(file, []byte("User-agent: *"), 0644)
+	r := New()
+	r.StaticFile("/robots.txt", file)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "User-agent: *" {
+		t.Fatalf("GET: %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodHead, "/robots.txt", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("Content-Length") != "13" {
+		t.Fatalf("HEAD: %d %q %q", w.Code, w.Body.String(), w.Header().Get("Content-Length"))
+	}
+}
+
+func TestStaticPrecompressed(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "app.js"), []byte("plain"), 0644)
+	os.WriteFile(filepath.Join(dir, "app.js.gz"), []byte("gzipped"), 0644)
+	os.WriteFile(filepath.Join(dir, "app.js.br"), []byte("brotli"), 0644)
+	r := New()
+	r.StaticWithConfig("/assets", dir, StaticConfig{Precompressed: true})
+
+	cases := []struct {
+		accept   string
+		encoding string
+		body     string
+	}{
+		{"", "", "plain"},
+		{"gzip, deflate", "gzip", "gzipped"},
+		{"gzip, br", "br", "brotli"},
+		{"br;q=0, gzip", "gzip", "gzipped"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/assets/app.js", nil)
+		req.Header.Set("Accept-Encoding", tc.accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Body.String() != tc.body || w.Header().Get("Content-Encoding") != tc.encoding {
+			t.Errorf("Accept-Encoding %q: %q with encoding %q", tc.accept, w.Body.String(), w.Header().Get("Content-Encoding"))
+		}
+		if ct := w.Header().Get("Content-Type"); ct != "text/javascript; charset=utf-8" && ct != "application/javascript" {
+			t.Errorf("Accept-Encoding %q: Content-Type %q", tc.accept, ct)
+		}
+	}
+}
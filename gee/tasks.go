@@ -0,0 +1,88 @@
+package gee
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// tasks 跟踪 engine 启动的后台 goroutine，Shutdown 时取消 ctx 并等待它们退出
+type tasks struct {
+	once   sync.Once
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (t *tasks) init() {
+	t.once.Do(func() {
+		t.ctx, t.cancel = context.WithCancel(context.Background())
+	})
+}
+
+// Go runs fn in a background goroutine owned by the engine. ctx is
+// canceled when Shutdown is called, and Shutdown waits for fn to return
+// before running the OnShutdown hooks. A panic in fn is logged, not fatal.
+func (engine *Engine) Go(fn func(ctx context.Context)) {
+	t := &engine.tasks
+	t.init()
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		defer func() {
+			if err := recover(); err != nil {
+				message := fmt.Sprintf("%s", err)
+				engine.logger.Log(LevelError, "background task panic", "error", message, "trace", trace(message))
+			}
+		}()
+		fn(t.ctx)
+	}()
+}
+
+// Every runs fn every interval in a background task until Shutdown. A run
+// that takes longer than interval delays the next one instead of
+// overlapping with it.
+func (engine *Engine) Every(interval time.Duration, fn func(ctx context.Context)) {
+	engine.Go(func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				engine.runTask(ctx, fn)
+			}
+		}
+	})
+}
+
+// runTask 单独 recover，某一次执行 panic 不会让定时任务停掉
+func (engine *Engine) runTask(ctx context.Context, fn func(ctx context.Context)) {
+	defer func() {
+		if err := recover(); err != nil {
+			message := fmt.Sprintf("%s", err)
+			engine.logger.Log(LevelError, "periodic task panic", "error", message, "trace", trace(message))
+		}
+	}()
+	fn(ctx)
+}
+
+// stopTasks 取消所有后台任务并等待退出，ctx 结束时不再等待
+func (engine *Engine) stopTasks(ctx context.Context) error {
+	t := &engine.tasks
+	t.init()
+	t.cancel()
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
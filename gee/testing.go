@@ -0,0 +1,24 @@
+package gee
+
+import "net/http"
+
+// CreateTestContext returns a Context for req that writes to w, bound to a
+// new Engine, so handlers and middleware can be unit-tested without going
+// through the router. See package gee/geetest for higher level helpers.
+func CreateTestContext(w http.ResponseWriter, req *http.Request) (*Context, *Engine) {
+	engine := New()
+	c := newContext(w, req)
+	c.engine = engine
+	c.group = engine.RouterGroup
+	return c, engine
+}
+
+// RunTestContext runs handlers on c as if they were the matched route's
+// chain, then sends a pending header and runs the OnFinish hooks.
+func RunTestContext(c *Context, handlers ...HandlerFunc) {
+	c.handlers = handlers
+	c.index = -1
+	defer c.runFinish()
+	c.Next()
+	c.Writer.WriteHeaderNow()
+}
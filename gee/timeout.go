@@ -0,0 +1,226 @@
+package gee
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeoutWriter 是可以被“封存”的 ResponseWriter。
+// 超时后它被封存，迟到的处理函数再写入都会被丢弃，不会和 504 响应重复写。
+// 所有字段都由 mu 保护。
+type timeoutWriter struct {
+	w           ResponseWriter
+	h           http.Header
+	mu          sync.Mutex
+	sealed      bool
+	wroteHeader bool
+}
+
+var _ ResponseWriter = (*timeoutWriter)(nil)
+
+func (tw *timeoutWriter) Header() http.Header {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	if tw.sealed || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		dst[k] = vv
+	}
+	tw.w.WriteHeader(code)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.sealed {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeaderLocked(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.w.Status()
+}
+
+func (tw *timeoutWriter) Size() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.w.Size()
+}
+
+func (tw *timeoutWriter) Written() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.w.Written()
+}
+
+func (tw *timeoutWriter) WriteHeaderNow() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.sealed {
+		tw.writeHeaderLocked(http.StatusOK)
+		tw.w.WriteHeaderNow()
+	}
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if !tw.sealed {
+		tw.writeHeaderLocked(http.StatusOK)
+		tw.w.Flush()
+	}
+}
+
+// Hijack 不支持：超时后连接仍要用来写 504
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("gee: Hijack is not supported inside Timeout")
+}
+
+func (tw *timeoutWriter) Push(target string, opts *http.PushOptions) error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.sealed {
+		return http.ErrHandlerTimeout
+	}
+	return tw.w.Push(target, opts)
+}
+
+// flushHeaders 在处理函数正常返回后把头交给下层 writer：
+// 还没写过响应头时是全部的头，否则只有写完 body 之后设置的 trailer
+func (tw *timeoutWriter) flushHeaders() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	dst := tw.w.Header()
+	for k, vv := range tw.h {
+		if !tw.wroteHeader || strings.HasPrefix(k, http.TrailerPrefix) {
+			dst[k] = vv
+		}
+	}
+}
+
+// handOff 在 writer 还没封存时把处理函数的 panic 交给 Timeout 重新抛出，
+// 返回 false 表示已经超时，只能由调用方自己记录
+func (tw *timeoutWriter) handOff(p timeoutPanic, panicChan chan<- timeoutPanic) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.sealed {
+		return false
+	}
+	panicChan <- p
+	return true
+}
+
+// timeoutPanic 是处理函数 goroutine 里 recover 到的 panic 和当时的调用栈
+type timeoutPanic struct {
+	err   interface{}
+	trace string
+}
+
+// seal 封存 writer；如果还没有向客户端发出过响应头，在持有锁的情况下调用 onTimeout 写超时响应
+func (tw *timeoutWriter) seal(onTimeout func()) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.sealed = true
+	if !tw.w.Written() {
+		onTimeout()
+	}
+}
+
+// Timeout runs the rest of the handler chain with a deadline of d. When the
+// deadline is exceeded the client gets 504 and anything the handler writes
+// afterwards is dropped. Keys, errors and OnFinish hooks the handlers add
+// are kept when they finish in time; a panic after the deadline is logged.
+func Timeout(d time.Duration) HandlerFunc {
+	return func(c *Context) {
+		ctx, cancel := context.WithTimeout(c.Req.Context(), d)
+		defer cancel()
+
+		tw := &timeoutWriter{w: c.Writer, h: make(http.Header)}
+		// 后续处理函数在另一个 goroutine 中执行，给它一份 Context 的拷贝，
+		// 可变的 Keys、Errors 和 finish 也各拷贝一份，这样超时返回后两边不会同时读写；
+		// 按时完成时再把它们合并回来。
+		cc := *c
+		cc.Writer = tw
+		cc.Req = c.Req.WithContext(ctx)
+		cc.Keys = make(map[string]interface{}, len(c.Keys))
+		for k, v := range c.Keys {
+			cc.Keys[k] = v
+		}
+		cc.Errors = append([]error(nil), c.Errors...)
+		cc.finish = append([]func(){}, c.finish...)
+
+		done := make(chan struct{})
+		panicChan := make(chan timeoutPanic, 1)
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					message := fmt.Sprintf("%s", err)
+					p := timeoutPanic{err: err, trace: trace(message)}
+					if !tw.handOff(p, panicChan) {
+						logLatePanic(&cc, p)
+					}
+				}
+			}()
+			cc.Next()
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			// 在当前 goroutine 重新 panic，交给 Recovery 处理
+			panic(p.err)
+		case <-done:
+			tw.flushHeaders()
+			c.Keys = cc.Keys
+			c.Errors = cc.Errors
+			c.finish = cc.finish
+			c.fullPath = cc.fullPath
+			c.StatusCode = cc.StatusCode
+			c.index = cc.index
+		case <-ctx.Done():
+			tw.seal(func() {
+				c.Fail(http.StatusGatewayTimeout, http.StatusText(http.StatusGatewayTimeout))
+			})
+			c.index = len(c.handlers)
+			// 封存前已经交出来的 panic 不会再有人重新抛出，记录下来
+			select {
+			case p := <-panicChan:
+				logLatePanic(c, p)
+			default:
+			}
+		}
+	}
+}
+
+// logLatePanic 记录超时之后处理函数里发生的 panic，响应已经是 504，不能再交给 Recovery
+func logLatePanic(c *Context, p timeoutPanic) {
+	c.logger().Log(LevelError, "panic after timeout",
+		"error", fmt.Sprintf("%s", p.err),
+		"method", c.Method,
+		"path", c.Path,
+		"trace", p.trace)
+}
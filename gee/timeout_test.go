@@ -0,0 +1,99 @@
+package gee
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	r := New()
+	r.Use(Timeout(20 * time.Millisecond))
+	r.GET("/fast", func(c *Context) {
+		c.String(http.StatusOK, "fast")
+	})
+	r.GET("/slow", func(c *Context) {
+		<-c.Req.Context().Done()
+		time.Sleep(10 * time.Millisecond)
+		c.String(http.StatusOK, "slow")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/fast", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "fast" {
+		t.Fatalf("fast handler should not time out, got %d %q", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("slow handler should time out with 504, got %d", w.Code)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if strings.Contains(w.Body.String(), "slow") {
+		t.Fatalf("late handler should not write the response")
+	}
+}
+
+func TestTimeoutKeepsContext(t *testing.T) {
+	r := New()
+	var keys map[string]interface{}
+	var errs []error
+	r.Use(func(c *Context) {
+		c.Next()
+		keys, errs = c.Keys, c.Errors
+	})
+	r.Use(Timeout(time.Second))
+	r.GET("/hello", func(c *Context) {
+		c.Set("user", "geektutu")
+		c.Error(errors.New("boom"))
+		// 只设置头、不写 body 也要保留
+		c.SetHeader("X-Only", "1")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/hello", nil))
+	if w.Header().Get("X-Only") != "1" {
+		t.Fatalf("headers set without a body should be sent, got %v", w.Header())
+	}
+	if keys["user"] != "geektutu" || len(errs) != 1 {
+		t.Fatalf("keys and errors should be kept, got %v %v", keys, errs)
+	}
+}
+
+// chanLogger 把每条日志的消息发到 ch
+type chanLogger chan string
+
+func (l chanLogger) Enabled(level Level) bool { return true }
+
+func (l chanLogger) Log(level Level, msg string, keysAndValues ...interface{}) { l <- msg }
+
+func TestTimeoutLatePanic(t *testing.T) {
+	r := New()
+	logs := make(chanLogger, 10)
+	r.SetLogger(logs)
+	r.Use(Timeout(10 * time.Millisecond))
+	r.GET("/late", func(c *Context) {
+		<-c.Req.Context().Done()
+		panic("too late")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/late", nil))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected 504, got %d", w.Code)
+	}
+	for {
+		select {
+		case msg := <-logs:
+			if msg == "panic after timeout" {
+				return
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("a panic after the timeout should be logged")
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package gee
+
+import "net/http"
+
+// WrapH adapts a net/http handler, e.g. promhttp.Handler(), to a gee
+// HandlerFunc. The handler writes through c.Writer, so status and size are
+// still seen by Logger and other middleware.
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Writer, c.Req)
+	}
+}
+
+// WrapF adapts a net/http handler function to a gee HandlerFunc.
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return WrapH(f)
+}
@@ -0,0 +1,38 @@
+package gee
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWrapH(t *testing.T) {
+	var logs bytes.Buffer
+	r := New()
+	r.Use(LoggerWithConfig(LoggerConfig{Output: &logs}))
+	r.GET("/metrics", WrapH(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("up 1"))
+	})))
+	r.GET("/hello/:name", WrapF(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("hello " + req.URL.Path))
+	}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusAccepted || w.Body.String() != "up 1" {
+		t.Fatalf("WrapH: %d %q", w.Code, w.Body.String())
+	}
+	// 包装的处理函数通过 c.Writer 写响应，中间件能看到状态码
+	if !strings.Contains(logs.String(), " 202 ") {
+		t.Fatalf("Logger should see the wrapped handler's status:\n%s", logs.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/hello/gee", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "hello /hello/gee" {
+		t.Fatalf("WrapF: %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,80 @@
+package geecache
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// adminPrefix 位于 basePath 之下，开启后同名的分组 admin 无法再通过节点协议访问
+const adminPrefix = "admin/"
+
+// EnableAdmin turns on the admin routes under <basePath>admin/. Requests
+// must carry "Authorization: Bearer <token>".
+//
+//	GET  /_geecache/admin/stats             counters of every group
+//	GET  /_geecache/admin/groups            configuration and counters of every group
+//	GET  /_geecache/admin/keys?group=name   keys cached by the group
+//	POST /_geecache/admin/purge?group=name  clear the group, or one key with &key=
+//
+// The routes only act on this node.
+func (p *HTTPPool) EnableAdmin(token string) {
+	if token == "" {
+		panic("geecache: empty admin token")
+	}
+	p.adminToken = token
+}
+
+func (p *HTTPPool) serveAdmin(w http.ResponseWriter, r *http.Request, route string) {
+	if !bearerMatches(r, p.adminToken) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="geecache"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch route {
+	case "stats":
+		stats := make(map[string]Stats)
+		for _, info := range Groups() {
+			stats[info.Name] = info.Stats
+		}
+		writeJSON(w, stats)
+	case "groups":
+		writeJSON(w, Groups())
+	case "keys":
+		g := GetGroup(r.URL.Query().Get("group"))
+		if g == nil {
+			http.Error(w, "no such group: "+r.URL.Query().Get("group"), http.StatusNotFound)
+			return
+		}
+		keys := append(g.mainCache.keys(), g.hotCache.keys()...)
+		sort.Strings(keys)
+		writeJSON(w, keys)
+	case "purge":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		g := GetGroup(r.URL.Query().Get("group"))
+		if g == nil {
+			http.Error(w, "no such group: "+r.URL.Query().Get("group"), http.StatusNotFound)
+			return
+		}
+		if key := r.URL.Query().Get("key"); key != "" {
+			g.removeLocally(key)
+		} else {
+			g.Clear()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,93 @@
+package geecache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestAdmin(t *testing.T) {
+	g := NewGroup("admin-test", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	g.Set("Tom", NewByteView([]byte("630")), 0)
+	g.Set("Jack", NewByteView([]byte("589")), 0)
+
+	pool := NewHTTPPool("self")
+	pool.EnableAdmin("secret")
+	do := func(method string, target string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		pool.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do("GET", "/_geecache/admin/stats", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token should be rejected, got %d", w.Code)
+	}
+
+	w := do("GET", "/_geecache/admin/stats", "secret")
+	var stats map[string]Stats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil || stats["admin-test"].Items != 2 {
+		t.Fatalf("stats should report 2 items, got %s", w.Body)
+	}
+
+	w = do("GET", "/_geecache/admin/keys?group=admin-test", "secret")
+	var keys []string
+	if err := json.Unmarshal(w.Body.Bytes(), &keys); err != nil || !reflect.DeepEqual(keys, []string{"Jack", "Tom"}) {
+		t.Fatalf("keys = %s", w.Body)
+	}
+
+	if w := do("GET", "/_geecache/admin/purge?group=admin-test", "secret"); w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("purge should require POST, got %d", w.Code)
+	}
+	do("POST", "/_geecache/admin/purge?group=admin-test&key=Tom", "secret")
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatalf("purge with key should remove only that key")
+	}
+	do("POST", "/_geecache/admin/purge?group=admin-test", "secret")
+	if st := g.Stats(); st.Items != 0 {
+		t.Fatalf("purge should clear the group, got %+v", st)
+	}
+}
+
+func TestAdminDisabled(t *testing.T) {
+	w := httptest.NewRecorder()
+	NewHTTPPool("self").ServeHTTP(w, httptest.NewRequest("GET", "/_geecache/admin/stats", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("admin routes should be off by default, got %d", w.Code)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	NewGroup("groups-b", 100, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	NewGroup("groups-a", 800, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithTTL(time.Minute), WithHotCache(100, 10, time.Minute))
+	defer DestroyGroup("groups-a")
+	defer DestroyGroup("groups-b")
+
+	var names []string
+	var a GroupInfo
+	for _, info := range Groups() {
+		names = append(names, info.Name)
+		if info.Name == "groups-a" {
+			a = info
+		}
+	}
+	if !sort.StringsAreSorted(names) {
+		t.Fatalf("Groups should be sorted by name, got %v", names)
+	}
+	if a.CacheBytes != 800 || a.HotCacheBytes != 100 || a.TTL != time.Minute {
+		t.Fatalf("unexpected info %+v", a)
+	}
+}
@@ -0,0 +1,208 @@
+// Package arc implements an Adaptive Replacement Cache with the same byte
+// accounting as package lru.
+package arc
+
+import (
+	"container/list"
+
+	"geecache/lru"
+)
+
+// Value use Len to count how many bytes it takes
+type Value = lru.Value
+
+// arc Cache, 并发访问不安全。
+// t1 保存只访问过一次的条目，t2 保存访问过多次的条目；b1、b2 是从 t1、t2
+// 淘汰出去的 key（只记大小不存值）。命中 b1 说明 t1 太小，命中 b2 说明 t2
+// 太小，p 是按字节计的 t1 目标大小，随之自动调整。
+type Cache struct {
+	maxBytes int64
+	p        int64
+	lists    [4]*list.List
+	bytes    [4]int64
+	cache    map[string]*list.Element
+	// OnEvicted 在条目被淘汰或删除时调用
+	OnEvicted func(key string, value Value)
+}
+
+const (
+	t1 = iota
+	t2
+	b1
+	b2
+)
+
+type entry struct {
+	key   string
+	value Value
+	// size 是 key 和值的总字节数，进入 b1、b2 后值被丢弃，size 保留
+	size  int64
+	which int
+}
+
+func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
+	// 负数和 0 一样表示不限制，否则会一直淘汰到缓存为空
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	c := &Cache{
+		maxBytes:  maxBytes,
+		cache:     make(map[string]*list.Element),
+		OnEvicted: onEvicted,
+	}
+	for i := range c.lists {
+		c.lists[i] = list.New()
+	}
+	return c
+}
+
+func (c *Cache) Get(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		if kv.which == t1 || kv.which == t2 {
+			c.move(ele, t2)
+			return kv.value, true
+		}
+	}
+	return
+}
+
+// Peek returns the value of key without counting as an access.
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		if kv := ele.Value.(*entry); kv.which == t1 || kv.which == t2 {
+			return kv.value, true
+		}
+	}
+	return
+}
+
+// move 把条目移到 which 链表的头部
+func (c *Cache) move(ele *list.Element, which int) {
+	kv := ele.Value.(*entry)
+	c.lists[kv.which].Remove(ele)
+	c.bytes[kv.which] -= kv.size
+	kv.which = which
+	c.cache[kv.key] = c.lists[which].PushFront(kv)
+	c.bytes[which] += kv.size
+}
+
+func (c *Cache) Add(key string, value Value) {
+	size := int64(len(key)) + int64(value.Len())
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		switch kv.which {
+		case b1:
+			// t1 淘汰得太早，扩大 t1
+			c.p += size * ratio(c.bytes[b2], c.bytes[b1])
+			if c.p > c.maxBytes {
+				c.p = c.maxBytes
+			}
+		case b2:
+			// t2 淘汰得太早，缩小 t1
+			c.p -= size * ratio(c.bytes[b1], c.bytes[b2])
+			if c.p < 0 {
+				c.p = 0
+			}
+		}
+		c.bytes[kv.which] += size - kv.size
+		kv.value, kv.size = value, size
+		c.move(ele, t2)
+	} else {
+		kv := &entry{key: key, value: value, size: size, which: t1}
+		c.cache[key] = c.lists[t1].PushFront(kv)
+		c.bytes[t1] += size
+	}
+	for c.maxBytes != 0 && c.maxBytes < c.Bytes() {
+		c.RemoveOldest()
+	}
+	c.trimGhosts()
+}
+
+// ratio 返回 a/b，至少为 1
+func ratio(a, b int64) int64 {
+	if b == 0 || a <= b {
+		return 1
+	}
+	return a / b
+}
+
+// RemoveOldest evicts the least recently used entry of t1 while t1 is above
+// its adaptive target, and of t2 otherwise.
+func (c *Cache) RemoveOldest() {
+	from, to := t2, b2
+	if c.lists[t1].Len() > 0 && (c.bytes[t1] > c.p || c.lists[t2].Len() == 0) {
+		from, to = t1, b1
+	}
+	ele := c.lists[from].Back()
+	if ele == nil {
+		return
+	}
+	kv := ele.Value.(*entry)
+	value := kv.value
+	kv.value = nil
+	c.move(ele, to)
+	if c.OnEvicted != nil {
+		c.OnEvicted(kv.key, value)
+	}
+}
+
+// trimGhosts 限制 b1、b2 记录的字节数：t1+b1 和四个链表的总和分别不超过 maxBytes 和它的两倍
+func (c *Cache) trimGhosts() {
+	if c.maxBytes == 0 {
+		return
+	}
+	for c.bytes[t1]+c.bytes[b1] > c.maxBytes && c.lists[b1].Len() > 0 {
+		c.dropGhost(b1)
+	}
+	for c.Bytes()+c.bytes[b1]+c.bytes[b2] > 2*c.maxBytes && c.lists[b2].Len() > 0 {
+		c.dropGhost(b2)
+	}
+}
+
+func (c *Cache) dropGhost(which int) {
+	ele := c.lists[which].Back()
+	kv := ele.Value.(*entry)
+	c.lists[which].Remove(ele)
+	c.bytes[which] -= kv.size
+	delete(c.cache, kv.key)
+}
+
+// Remove removes the provided key from the cache.
+func (c *Cache) Remove(key string) {
+	ele, ok := c.cache[key]
+	if !ok {
+		return
+	}
+	kv := ele.Value.(*entry)
+	c.lists[kv.which].Remove(ele)
+	c.bytes[kv.which] -= kv.size
+	delete(c.cache, key)
+	if (kv.which == t1 || kv.which == t2) && c.OnEvicted != nil {
+		c.OnEvicted(kv.key, kv.value)
+	}
+}
+
+// Range calls fn for each cached entry, the frequently used ones first,
+// without counting as an access, until fn returns false. fn must not modify
+// the cache.
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for _, which := range []int{t2, t1} {
+		for ele := c.lists[which].Front(); ele != nil; ele = ele.Next() {
+			kv := ele.Value.(*entry)
+			if !fn(kv.key, kv.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *Cache) Len() int {
+	return c.lists[t1].Len() + c.lists[t2].Len()
+}
+
+// Bytes returns the number of bytes currently held, keys included. Keys
+// remembered after eviction are not counted.
+func (c *Cache) Bytes() int64 {
+	return c.bytes[t1] + c.bytes[t2]
+}
@@ -0,0 +1,88 @@
+package arc
+
+import (
+	"fmt"
+	"testing"
+)
+
+type String string
+
+func (d String) Len() int {
+	return len(d)
+}
+
+func TestGet(t *testing.T) {
+	arc := New(int64(0), nil)
+	arc.Add("key1", String("1234"))
+	if v, ok := arc.Get("key1"); !ok || string(v.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := arc.Get("key2"); ok {
+		t.Fatalf("cache miss key2 failed")
+	}
+}
+
+func TestNegativeLimit(t *testing.T) {
+	arc := New(int64(-1), nil)
+	arc.Add("key1", String("1"))
+	arc.Add("key2", String("2"))
+	if arc.Len() != 2 {
+		t.Fatalf("a negative limit should mean no limit, got %d entries", arc.Len())
+	}
+}
+
+func TestScanResistance(t *testing.T) {
+	evicted := 0
+	arc := New(int64(4*4), func(key string, value Value) {
+		evicted++
+	})
+	arc.Add("h1", String("v1"))
+	arc.Add("h2", String("v2"))
+	arc.Get("h1")
+	arc.Get("h2")
+	// 只访问一次的 key 只在 t1 里互相淘汰，不会挤掉 t2 中的 h1、h2
+	for i := 0; i < 10; i++ {
+		arc.Add(fmt.Sprintf("s%d", i), String("vv"))
+	}
+	for _, key := range []string{"h1", "h2"} {
+		if _, ok := arc.Get(key); !ok {
+			t.Fatalf("%s should survive the scan", key)
+		}
+	}
+	if arc.Len() != 4 || arc.Bytes() != 16 || evicted != 8 {
+		t.Fatalf("Len = %d, Bytes = %d, evicted = %d", arc.Len(), arc.Bytes(), evicted)
+	}
+}
+
+func TestGhostHit(t *testing.T) {
+	arc := New(int64(2*4), nil)
+	arc.Add("k1", String("v1"))
+	arc.Add("k2", String("v2"))
+	arc.Get("k2")
+	arc.Add("k3", String("v3"))
+	if _, ok := arc.Get("k1"); ok {
+		t.Fatalf("k1 should be evicted")
+	}
+	// k1 还记在 b1 里，再次加入时直接进入 t2，并扩大 t1 的目标大小
+	arc.Add("k1", String("v1"))
+	if arc.p == 0 {
+		t.Fatalf("a hit in b1 should grow the target size of t1")
+	}
+	if ele := arc.cache["k1"]; ele.Value.(*entry).which != t2 {
+		t.Fatalf("k1 should be moved to t2")
+	}
+	if arc.Len() != 2 || arc.Bytes() != 8 {
+		t.Fatalf("Len = %d, Bytes = %d", arc.Len(), arc.Bytes())
+	}
+}
+
+func TestRemove(t *testing.T) {
+	arc := New(int64(0), nil)
+	arc.Add("key1", String("1234"))
+	arc.Add("key2", String("5678"))
+	arc.Get("key1")
+	arc.Remove("key1")
+	if _, ok := arc.Get("key1"); ok || arc.Len() != 1 || arc.Bytes() != int64(len("key2")+4) {
+		t.Fatalf("Remove key1 failed")
+	}
+}
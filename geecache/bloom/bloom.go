@@ -0,0 +1,73 @@
+package bloom
+
+import (
+	"math"
+	"sync"
+)
+
+// Filter 是布隆过滤器：Test 返回 false 时 key 一定没有被 Add 过，
+// 返回 true 时有 fpRate 左右的概率误判。并发安全。
+type Filter struct {
+	mu   sync.RWMutex
+	bits []uint64
+	m    uint64 // 位数
+	k    uint64 // 哈希函数个数
+}
+
+// New creates a Filter sized for n keys with a false positive rate of
+// about fpRate.
+func New(n int, fpRate float64) *Filter {
+	if n <= 0 {
+		n = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+	return &Filter{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Add records key in the filter.
+func (f *Filter) Add(key string) {
+	h1, h2 := hash(key)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// Test reports whether key may have been added. false means it never was.
+func (f *Filter) Test(key string) bool {
+	h1, h2 := hash(key)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hash 用 fnv-1a 的 64 位结果拆出两个哈希，第 i 个哈希函数是 h1 + i*h2
+func hash(key string) (uint64, uint64) {
+	var h uint64 = 14695981039346656037
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	h1, h2 := h&0xffffffff, h>>32
+	// h2 为 0 时所有哈希函数都落在同一位
+	return h1, h2 | 1
+}
@@ -0,0 +1,28 @@
+package bloom
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	f := New(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		f.Add("key" + strconv.Itoa(i))
+	}
+	for i := 0; i < 1000; i++ {
+		if !f.Test("key" + strconv.Itoa(i)) {
+			t.Fatalf("added key%d must test true", i)
+		}
+	}
+
+	fp := 0
+	for i := 0; i < 10000; i++ {
+		if f.Test("other" + strconv.Itoa(i)) {
+			fp++
+		}
+	}
+	if rate := float64(fp) / 10000; rate > 0.03 {
+		t.Fatalf("false positive rate %.3f is far above 0.01", rate)
+	}
+}
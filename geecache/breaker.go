@@ -0,0 +1,87 @@
+package geecache
+
+import (
+	"sync"
+	"time"
+)
+
+// peerBreaker 为每个远程节点单独计数失败次数。
+// 连续失败达到 threshold 后熔断，coolDown 时间内不再请求该节点，直接走本地 getter；
+// 冷却结束后放行一个试探请求，成功则恢复，失败则继续熔断。
+type peerBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	coolDown  time.Duration
+	peers     map[PeerGetter]*breakerState
+}
+
+type breakerState struct {
+	failures  int
+	openUntil time.Time
+	probing   bool // 半开状态下已有试探请求在进行
+}
+
+func newPeerBreaker(threshold int, coolDown time.Duration) *peerBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	return &peerBreaker{
+		threshold: threshold,
+		coolDown:  coolDown,
+		peers:     make(map[PeerGetter]*breakerState),
+	}
+}
+
+// allow reports whether a request may be sent to peer now.
+func (b *peerBreaker) allow(peer PeerGetter) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s, ok := b.peers[peer]
+	if !ok || s.failures < b.threshold {
+		return true
+	}
+	if time.Now().Before(s.openUntil) || s.probing {
+		return false
+	}
+	s.probing = true
+	return true
+}
+
+// abort gives up a request sent to peer without recording an outcome,
+// e.g. because it was canceled.
+func (b *peerBreaker) abort(peer PeerGetter) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if s, ok := b.peers[peer]; ok {
+		s.probing = false
+	}
+}
+
+// done records the outcome of a request sent to peer.
+func (b *peerBreaker) done(peer PeerGetter, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		delete(b.peers, peer)
+		return
+	}
+	s, ok := b.peers[peer]
+	if !ok {
+		s = &breakerState{}
+		b.peers[peer] = s
+	}
+	s.failures++
+	s.probing = false
+	if s.failures >= b.threshold {
+		s.openUntil = time.Now().Add(b.coolDown)
+	}
+}
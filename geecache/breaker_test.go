@@ -0,0 +1,50 @@
+package geecache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPeerBreaker(t *testing.T) {
+	b := newPeerBreaker(2, 20*time.Millisecond)
+	peer := &httpGetter{baseURL: "http://localhost:8001/_geecache/"}
+	fail := errors.New("peer down")
+
+	b.done(peer, fail)
+	if !b.allow(peer) {
+		t.Fatalf("breaker should stay closed below the threshold")
+	}
+	b.done(peer, fail)
+	if b.allow(peer) {
+		t.Fatalf("breaker should open after 2 failures")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !b.allow(peer) {
+		t.Fatalf("breaker should let a probe through after cool-down")
+	}
+	if b.allow(peer) {
+		t.Fatalf("only one probe should be in flight")
+	}
+	b.done(peer, nil)
+	if !b.allow(peer) {
+		t.Fatalf("breaker should close after a successful probe")
+	}
+}
+
+func TestPeerBreakerAbort(t *testing.T) {
+	b := newPeerBreaker(1, time.Millisecond)
+	peer := &httpGetter{baseURL: "http://localhost:8001/_geecache/"}
+	b.done(peer, errors.New("peer down"))
+
+	time.Sleep(5 * time.Millisecond)
+	if !b.allow(peer) {
+		t.Fatalf("breaker should let a probe through after cool-down")
+	}
+	// 被取消的试探不算结果，下一个请求可以重新试探
+	b.abort(peer)
+	if !b.allow(peer) {
+		t.Fatalf("an aborted probe should free the probe slot")
+	}
+}
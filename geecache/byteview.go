@@ -1,7 +1,29 @@
 package geecache
 
+import "time"
+
 type ByteView struct {
 	b []byte
+	// e 是过期时间，零值表示永不过期
+	e time.Time
+	// version 是写入缓存时分组的版本，和当前版本不同的值视为失效
+	version int64
+}
+
+// NewByteView returns a view of a copy of b, so later changes to b don't
+// affect the cached value.
+func NewByteView(b []byte) ByteView {
+	return ByteView{b: cloneBytes(b)}
+}
+
+// Expire returns the time the view expires, or the zero time if it never
+// expires.
+func (v ByteView) Expire() time.Time {
+	return v.e
+}
+
+func (v ByteView) expired(now time.Time) bool {
+	return !v.e.IsZero() && now.After(v.e)
 }
 
 func (v ByteView) Len() int {
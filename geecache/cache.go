@@ -1,34 +1,241 @@
 package geecache
 
 import (
-	"geecache/lru"
 	"sync"
+	"time"
 )
 
+// cache 按 key 的哈希分成多个分片，每个分片有自己的锁和 Store，
+// 不同分片上的读写不会互相阻塞
 type cache struct {
-	mu         sync.Mutex
-	lru        *lru.Cache
 	cacheBytes int64
+	newStore   StoreFunc
+	// nshards 是分片数，0 表示只有一个分片，见 WithShards
+	nshards int
+	// stale 是过期后仍然保留、可以返回给调用者的时长，见 WithStaleWhileRevalidate
+	stale time.Duration
+	// onEvicted 在释放锁之后调用，回调里可以再访问缓存
+	onEvicted func(key string, value ByteView)
+
+	// shards 在第一次使用时创建，此时分组的选项都已经设置好了
+	once   sync.Once
+	shards []*cacheShard
+}
+
+// readBufferSize 是每个分片缓存的待更新访问记录数
+const readBufferSize = 64
+
+type cacheShard struct {
+	mu    sync.RWMutex
+	store Store
+	// evictions 是被 clear 丢掉的 store 累计的淘汰数
+	evictions int64
+	evicted   []evictedEntry
+	// reads 记录读锁下命中、还没有告诉 store 的访问，满了以后丢弃新的记录，
+	// 只影响淘汰顺序的精度
+	reads chan string
+}
+
+type evictedEntry struct {
+	key   string
+	value ByteView
+}
+
+func (c *cache) init() {
+	c.once.Do(func() {
+		if c.nshards <= 0 {
+			c.nshards = 1
+		}
+		if c.newStore == nil {
+			c.newStore = NewLRUStore
+		}
+		c.shards = make([]*cacheShard, c.nshards)
+		for i := range c.shards {
+			c.shards[i] = &cacheShard{reads: make(chan string, readBufferSize)}
+		}
+	})
+}
+
+// shard 返回 key 所在的分片
+func (c *cache) shard(key string) *cacheShard {
+	c.init()
+	if len(c.shards) == 1 {
+		return c.shards[0]
+	}
+	return c.shards[fnv64a(key)%uint64(len(c.shards))]
+}
+
+// each 依次对每个分片加锁后调用 fn
+func (c *cache) each(fn func(s *cacheShard)) {
+	c.init()
+	for _, s := range c.shards {
+		func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			fn(s)
+		}()
+	}
+}
+
+// shardBytes 是每个分片的字节上限。cacheBytes 比分片数小时整除会得到 0，
+// 而 0 表示不限制，所以至少给每个分片 1 字节
+func (c *cache) shardBytes() int64 {
+	if c.cacheBytes <= 0 {
+		return c.cacheBytes
+	}
+	if n := c.cacheBytes / int64(len(c.shards)); n > 0 {
+		return n
+	}
+	return 1
 }
 
 func (c *cache) add(key string, value ByteView) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.lru == nil {
-		c.lru = lru.New(c.cacheBytes, nil)
+	// 淘汰回调在释放锁之后执行，回调里可以再访问缓存
+	for _, e := range c.addLocked(c.shard(key), key, value) {
+		c.onEvicted(e.key, e.value)
 	}
-	c.lru.Add(key, value)
+}
+
+// addLocked 在持有分片锁时写入，返回这次被淘汰的条目。
+// 用 defer 解锁，Store.Add panic 时分片也不会一直锁着
+func (c *cache) addLocked(s *cacheShard, key string, value ByteView) []evictedEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// panic 时也清掉这次收集的条目，不留给下一次 add
+	defer func() { s.evicted = nil }()
+	if s.store == nil {
+		s.store = c.newStore(c.shardBytes())
+		if c.onEvicted != nil {
+			s.store.SetOnEvicted(func(key string, value ByteView) {
+				s.evicted = append(s.evicted, evictedEntry{key, value})
+			})
+		}
+	}
+	// 先补上积压的访问，淘汰时才能按最新的顺序
+	s.applyReads()
+	s.store.Add(key, value)
+	return s.evicted
 }
 
 func (c *cache) get(key string) (value ByteView, ok bool) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	if c.lru == nil {
+	s := c.shard(key)
+	// store 支持 Peek 时在读锁下查找，命中的访问稍后批量交给 store
+	s.mu.RLock()
+	p, peek := s.store.(Peeker)
+	if peek {
+		value, ok = p.Peek(key)
+	}
+	s.mu.RUnlock()
+	if peek && (!ok || !value.expired(time.Now().Add(-c.stale))) {
+		if ok {
+			s.recordRead(key)
+		}
 		return
 	}
-	if v, ok := c.lru.Get(key); ok {
-		return v.(ByteView), ok
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store == nil {
+		return
+	}
+	value, ok = s.store.Get(key)
+	// 过期且超过 stale 时长的条目当作未命中，顺便删掉
+	if ok && value.expired(time.Now().Add(-c.stale)) {
+		s.store.Remove(key)
+		return ByteView{}, false
 	}
+	return
+}
 
+// recordRead 记下一次访问，缓冲区满了并且能立刻拿到写锁时批量更新
+func (s *cacheShard) recordRead(key string) {
+	select {
+	case s.reads <- key:
+	default:
+	}
+	if len(s.reads) == cap(s.reads) && s.mu.TryLock() {
+		defer s.mu.Unlock()
+		s.applyReads()
+	}
+}
+
+// applyReads 用 Get 把积压的访问告诉 store，调用时必须持有写锁
+func (s *cacheShard) applyReads() {
+	for {
+		select {
+		case key := <-s.reads:
+			if s.store != nil {
+				s.store.Get(key)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (c *cache) remove(key string) {
+	s := c.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.store != nil {
+		s.store.Remove(key)
+	}
+}
+
+// clear 丢掉所有分片的 store，下次 add 时重新创建，字节计数也随之归零
+func (c *cache) clear() {
+	c.each(func(s *cacheShard) {
+		if s.store != nil {
+			s.evictions += s.store.Evictions()
+		}
+		s.store = nil
+	})
+}
+
+func (c *cache) stats() (bytes int64, items int64, evictions int64) {
+	c.each(func(s *cacheShard) {
+		evictions += s.evictions
+		if s.store != nil {
+			bytes += s.store.Bytes()
+			items += int64(s.store.Len())
+			evictions += s.store.Evictions()
+		}
+	})
 	return
 }
+
+func (c *cache) keys() []string {
+	var keys []string
+	c.each(func(s *cacheShard) {
+		if s.store == nil {
+			return
+		}
+		s.store.Range(func(key string, value ByteView) bool {
+			keys = append(keys, key)
+			return true
+		})
+	})
+	return keys
+}
+
+// removeExpired 删除所有在 now 之前过期、并且超过 stale 时长的条目，返回删除的数量
+func (c *cache) removeExpired(now time.Time) int {
+	n := 0
+	c.each(func(s *cacheShard) {
+		if s.store == nil {
+			return
+		}
+		var keys []string
+		s.store.Range(func(key string, value ByteView) bool {
+			if value.expired(now.Add(-c.stale)) {
+				keys = append(keys, key)
+			}
+			return true
+		})
+		for _, key := range keys {
+			s.store.Remove(key)
+		}
+		n += len(keys)
+	})
+	return n
+}
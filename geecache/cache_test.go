@@ -0,0 +1,129 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestShards(t *testing.T) {
+	g := NewGroup("shards", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithShards(4))
+	defer DestroyGroup("shards")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				g.Get(context.Background(), fmt.Sprintf("key%d", (i*20+j)%50))
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(g.mainCache.keys()); n != 50 {
+		t.Fatalf("expected 50 cached keys, got %d", n)
+	}
+	used := 0
+	for _, s := range g.mainCache.shards {
+		if s.store != nil && s.store.Len() > 0 {
+			used++
+		}
+	}
+	if len(g.mainCache.shards) != 4 || used < 2 {
+		t.Fatalf("keys should spread over the 4 shards, %d used", used)
+	}
+	if _, items, _ := g.mainCache.stats(); items != 50 {
+		t.Fatalf("stats should sum all shards, got %d items", items)
+	}
+}
+
+func TestShardBytesClamped(t *testing.T) {
+	// 2 字节分给 4 个分片时每个分片不能变成不限制
+	c := cache{cacheBytes: 2, nshards: 4}
+	for i := 0; i < 100; i++ {
+		c.add(fmt.Sprint(i), NewByteView([]byte("v")))
+	}
+	if keys := c.keys(); len(keys) != 0 {
+		t.Fatalf("shards should stay bounded, kept %d keys", len(keys))
+	}
+}
+
+func TestBatchedReads(t *testing.T) {
+	c := cache{cacheBytes: 2 * (2 + 4), newStore: NewLFUStore}
+	c.add("k1", ByteView{b: []byte("1111")})
+	c.add("k2", ByteView{b: []byte("2222")})
+	for i := 0; i < 3; i++ {
+		if _, ok := c.get("k1"); !ok {
+			t.Fatalf("k1 should be cached")
+		}
+	}
+	if n := len(c.shards[0].reads); n != 3 {
+		t.Fatalf("hits should be buffered, got %d", n)
+	}
+	// add 之前先补上积压的访问，k1 的访问次数更多，淘汰的是 k2
+	c.add("k3", ByteView{b: []byte("3333")})
+	if _, ok := c.get("k1"); !ok {
+		t.Fatalf("k1 should be kept after its buffered hits are applied")
+	}
+	if _, ok := c.get("k2"); ok {
+		t.Fatalf("k2 should be evicted")
+	}
+}
+
+func TestConcurrentReads(t *testing.T) {
+	c := cache{cacheBytes: 2 << 10, newStore: NewLFUStore}
+	for i := 0; i < 10; i++ {
+		c.add(fmt.Sprintf("key%d", i), ByteView{b: []byte("value")})
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				key := fmt.Sprintf("key%d", (i+j)%10)
+				if _, ok := c.get(key); !ok {
+					t.Errorf("%s should be cached", key)
+					return
+				}
+				if j%100 == 0 {
+					c.add(key, ByteView{b: []byte("value")})
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+// panicStore 的 Add 总是 panic
+type panicStore struct {
+	Store
+}
+
+func (s panicStore) Add(key string, value ByteView) {
+	panic("store is broken")
+}
+
+func TestAddPanicUnlocks(t *testing.T) {
+	c := cache{cacheBytes: 2 << 10, newStore: func(maxBytes int64) Store {
+		return panicStore{NewLRUStore(maxBytes)}
+	}}
+	func() {
+		defer func() { recover() }()
+		c.add("Tom", NewByteView([]byte("630")))
+	}()
+	// 分片没有解锁的话这里会死锁
+	if _, ok := c.get("Tom"); ok {
+		t.Fatalf("failed add should not store Tom")
+	}
+	if s := c.shard("Tom"); !s.mu.TryLock() {
+		t.Fatalf("a panic in Store.Add should not leave the shard locked")
+	} else {
+		s.mu.Unlock()
+	}
+}
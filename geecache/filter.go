@@ -0,0 +1,64 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// peerFilterTimeout 限制 Set 把 key 广播给其他节点过滤器的时间
+const peerFilterTimeout = 5 * time.Second
+
+// KeyFilter tells which keys may exist at the origin, e.g. a
+// *bloom.Filter filled with every existing key at startup.
+type KeyFilter interface {
+	Add(key string)
+	// Test 返回 false 表示 key 一定不存在
+	Test(key string) bool
+}
+
+// WithKeyFilter rejects keys the filter says can't exist with ErrNotFound
+// before they reach peers or the getter, so floods of random keys don't
+// penetrate the cache. The application must Add new keys to the filter
+// of every node when it creates them; Group.Set does so itself, telling
+// peers that implement PeerKeyFilter.
+func WithKeyFilter(filter KeyFilter) GroupOption {
+	return func(g *Group) {
+		g.keyFilter = filter
+	}
+}
+
+// PeerKeyFilter is implemented by peers that can add a key to their
+// KeyFilter, so a key written with Group.Set on one node isn't rejected by
+// the others.
+type PeerKeyFilter interface {
+	AddKey(ctx context.Context, group string, key string) error
+}
+
+// addKey 把 key 加进本地和所有节点的过滤器
+func (g *Group) addKey(key string) error {
+	if g.keyFilter == nil {
+		return nil
+	}
+	g.keyFilter.Add(key)
+	if g.peers == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), peerFilterTimeout)
+	defer cancel()
+	return g.forEachPeer(nil, func(peer PeerGetter) error {
+		pf, ok := peer.(PeerKeyFilter)
+		if !ok {
+			return nil
+		}
+		if err := pf.AddKey(ctx, g.name, key); err != nil {
+			return fmt.Errorf("add %s to the filter of %s: %w", key, peerName(peer), err)
+		}
+		return nil
+	})
+}
+
+// mayExist 在没有设置过滤器时总是返回 true
+func (g *Group) mayExist(key string) bool {
+	return g.keyFilter == nil || g.keyFilter.Test(key)
+}
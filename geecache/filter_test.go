@@ -0,0 +1,79 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"geecache/bloom"
+	"testing"
+)
+
+func TestKeyFilter(t *testing.T) {
+	filter := bloom.New(100, 0.01)
+	for k := range db {
+		filter.Add(k)
+	}
+	loads := 0
+	g := NewGroup("filtered", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(db[key]), nil
+	}), WithKeyFilter(filter))
+	ctx := context.Background()
+
+	if v, err := g.Get(ctx, "Tom"); err != nil || v.String() != "630" {
+		t.Fatalf("known key should load, got %q %v", v, err)
+	}
+	if _, err := g.Get(ctx, "random-key-123"); !errors.Is(err, ErrNotFound) || loads != 1 {
+		t.Fatalf("unknown key should be rejected before the getter, err = %v, loads = %d", err, loads)
+	}
+
+	g.Set("newcomer", NewByteView([]byte("1")), 0)
+	g.Remove(ctx, "newcomer")
+	if _, err := g.Get(ctx, "newcomer"); err != nil {
+		t.Fatalf("Set should add the key to the filter, got %v", err)
+	}
+}
+
+// filterPeer 是记录过滤器添加请求的 fakePeer
+type filterPeer struct {
+	fakePeer
+	added []string
+}
+
+func (p *filterPeer) AddKey(ctx context.Context, group string, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.added = append(p.added, group+"/"+key)
+	return nil
+}
+
+func TestSetBroadcastsFilter(t *testing.T) {
+	peer := &filterPeer{fakePeer: fakePeer{name: "p"}}
+	g := NewGroup("filtered-broadcast", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithKeyFilter(bloom.New(100, 0.01)))
+	g.RegisterPeers(singlePicker{peer: peer})
+
+	if err := g.Set("newcomer", NewByteView([]byte("1")), 0); err != nil {
+		t.Fatal(err)
+	}
+	if len(peer.added) != 1 || peer.added[0] != "filtered-broadcast/newcomer" {
+		t.Fatalf("Set should add the key to the peers' filters, got %v", peer.added)
+	}
+}
+
+func TestHTTPAddKey(t *testing.T) {
+	filter := bloom.New(100, 0.01)
+	NewGroup("http-filter", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithKeyFilter(filter))
+	srv, peer := newPeerServer()
+	defer srv.Close()
+
+	stranger := &httpGetter{baseURL: peer.baseURL}
+	if err := stranger.AddKey(context.Background(), "http-filter", "Sam"); err == nil || filter.Test("Sam") {
+		t.Fatalf("adding keys without the peer secret should be refused")
+	}
+	if err := peer.AddKey(context.Background(), "http-filter", "Sam"); err != nil || !filter.Test("Sam") {
+		t.Fatalf("AddKey should add Sam to the filter, err = %v", err)
+	}
+}
@@ -16,22 +16,63 @@ package geecache
 // 在分布式缓存系统中，每个节点通常会维护一个本地缓存，用于存储从远程节点获取的数据，以减少对远程节点的访问。
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"geecache/singleflight"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type Getter interface {
 	Get(key string) ([]byte, error)
 }
 
+// GetterCtx loads data for a key like Getter, but receives the context of
+// the Group.Get call so a slow origin load can stop when the caller gives up.
+type GetterCtx interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
 type Group struct {
 	name      string
-	getter    Getter
+	getter    GetterCtx
 	mainCache cache
-	peers     PeerPicker
-	loader    *singleflight.Group
+	// hotCache 保存归属其他节点、但在本节点被频繁访问的 key，避免热点 key 压垮一个节点
+	hotCache cache
+	hotOneIn int
+	peers    PeerPicker
+	loader   *singleflight.Group
+	breaker  *peerBreaker
+	hooks    Hooks
+	// ttl 是从 getter 加载的条目的存活时间，0 表示永不过期
+	ttl time.Duration
+	// hotTTL 是热点缓存副本的最长存活时间，见 WithHotCache
+	hotTTL time.Duration
+	// ttlJitter 是 TTL 随机缩短的最大比例，见 WithTTLJitter
+	ttlJitter float64
+	// broadcastRemove 为 true 时 Remove 会通知所有节点，而不只是 key 的归属节点
+	broadcastRemove bool
+	stats           groupStats
+	// refreshWindow 大于 0 时，剩余存活时间小于它的值被命中后会在后台刷新
+	refreshWindow time.Duration
+	refreshing    sync.Map
+	keyFilter     KeyFilter
+	// refreshes 记录进行中的后台刷新
+	refreshes sync.WaitGroup
+	// loadSlots 限制同时调用 getter 的数量，没有限制时为 nil
+	loadSlots chan struct{}
+	loadWait  time.Duration
+	// version 用 sync/atomic 读写，见 BumpVersion
+	version int64
+	// maxValueSize 大于 0 时，超过它的值不进缓存
+	maxValueSize int
+	// negative 缓存不存在的 key，没有开启时为 nil
+	negative *negativeCache
+	// done 在 DestroyGroup 时关闭，用来停止后台的 goroutine
+	done chan struct{}
 }
 
 var (
@@ -45,7 +86,34 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key)
 }
 
-func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
+// GetterCtxFunc implements GetterCtx with a function.
+type GetterCtxFunc func(ctx context.Context, key string) ([]byte, error)
+
+func (f GetterCtxFunc) Get(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+// getterAdapter 让不关心 context 的 Getter 也能作为 GetterCtx 使用
+type getterAdapter struct {
+	getter Getter
+}
+
+func (a getterAdapter) Get(_ context.Context, key string) ([]byte, error) {
+	return a.getter.Get(key)
+}
+
+func NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption) *Group {
+	if getter == nil {
+		panic("nil Getter")
+	}
+	return NewGroupContext(name, cacheBytes, getterAdapter{getter}, opts...)
+}
+
+// NewGroupContext is like NewGroup but takes a GetterCtx. Its ctx carries
+// the values of the context passed to Group.Get and is canceled when the
+// group is destroyed. A group created earlier
+// with the same name is replaced and stopped as by DestroyGroup.
+func NewGroupContext(name string, cacheBytes int64, getter GetterCtx, opts ...GroupOption) *Group {
 	if getter == nil {
 		panic("nil Getter")
 	}
@@ -55,7 +123,26 @@ func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
 		name:      name,
 		getter:    getter,
 		mainCache: cache{cacheBytes: cacheBytes},
+		hotOneIn:  defaultHotCacheOneIn,
 		loader:    &singleflight.Group{},
+		done:      make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if g.negative != nil {
+		g.negative.init(cacheBytes)
+	}
+	if g.hotCache.newStore == nil {
+		g.hotCache.newStore = g.mainCache.newStore
+	}
+	if g.ttl > 0 {
+		go g.sweep(g.ttl)
+	}
+	// 同名的旧分组被替换，和 DestroyGroup 一样停掉它的后台任务
+	if old, ok := groups[name]; ok {
+		close(old.done)
+		old.Clear()
 	}
 	groups[name] = g
 	return g
@@ -70,19 +157,82 @@ func GetGroup(name string) *Group {
 	return g
 }
 
-// Get value for a key from cache
+// DestroyGroup removes the named group, stops its background work and
+// releases its cache memory. Peer requests for the group answer 404 from
+// then on. The group must not be used after it is destroyed.
+func DestroyGroup(name string) {
+	mu.Lock()
+	g, ok := groups[name]
+	delete(groups, name)
+	mu.Unlock()
+	if !ok {
+		return
+	}
+	close(g.done)
+	g.Clear()
+}
+
+// Get value for a key from cache. Concurrent loads of the same key are
+// merged into one, which runs with the values of the first caller's ctx
+// but is only canceled when the group is destroyed, so one caller giving
+// up doesn't fail the others. ctx bounds how long this caller waits.
 // 在缓存中找数据
-func (g *Group) Get(key string) (ByteView, error) {
+func (g *Group) Get(ctx context.Context, key string) (ByteView, error) {
+	return g.get(ctx, key, g.load)
+}
+
+// GetOrLoad is like Get, but a miss is loaded locally with loader instead
+// of the group's Getter, e.g. to carry request-specific credentials. The
+// result is cached as usual, except that a key owned by another peer only
+// goes into the hot cache, so this node doesn't keep a copy the owner's
+// invalidations never reach. Concurrent loads of the same key are still
+// merged, so a caller may receive a value loaded by another caller's loader.
+func (g *Group) GetOrLoad(ctx context.Context, key string, loader GetterCtx) (ByteView, error) {
+	if loader == nil {
+		return g.Get(ctx, key)
+	}
+	return g.get(ctx, key, func(ctx context.Context, key string) (ByteView, error) {
+		return g.do(ctx, key, func(ctx context.Context) (ByteView, error) {
+			remote := false
+			if g.peers != nil {
+				_, remote = g.peers.PickPeer(key)
+			}
+			if !remote {
+				return g.getLocallyWith(ctx, key, loader)
+			}
+			value, err := g.loadWith(ctx, key, loader)
+			if err == nil {
+				g.populateHotCache(key, value)
+			}
+			return value, err
+		})
+	})
+}
+
+// get 先查缓存、过滤器和负缓存，都没有结果时交给 load
+func (g *Group) get(ctx context.Context, key string, load func(context.Context, string) (ByteView, error)) (ByteView, error) {
 	if key == "" {
 		return ByteView{}, fmt.Errorf("key is required")
 	}
 
-	if v, ok := g.mainCache.get(key); ok {
+	atomic.AddInt64(&g.stats.gets, 1)
+	start := time.Now()
+	if v, ok := g.lookupCache(key); ok {
+		atomic.AddInt64(&g.stats.hits, 1)
 		log.Println("[GeeCache] hit")
+		g.emit(g.hooks.OnHit, Event{Key: key, Bytes: v.Len(), Duration: time.Since(start)})
 		return v, nil
 	}
+	atomic.AddInt64(&g.stats.misses, 1)
+	g.emit(g.hooks.OnMiss, Event{Key: key})
+	if !g.mayExist(key) {
+		return ByteView{}, ErrNotFound
+	}
+	if err := g.negative.get(key); err != nil {
+		return ByteView{}, err
+	}
 
-	return g.load(key)
+	return load(ctx, key)
 }
 
 // 将 getLocally 封装在 load 方法中也可以使得后续对获取数据的逻辑进行修改或者扩展更加方便。
@@ -93,47 +243,264 @@ func (g *Group) Get(key string) (ByteView, error) {
 
 // 它首先检查是否已经注册了 PeerPicker，如果有注册，它会调用 PeerPicker 来选择一个远程节点，然后调用 getFromPeer 方法从选定的远程节点获取数据。
 // 如果获取成功，则返回获取到的数据；如果获取失败，则尝试从本地缓存中获取数据。如果未注册
-func (g *Group) load(key string) (value ByteView, err error) {
-	viewi, err := g.loader.Do(key, func() (interface{}, error) {
+// 同一个 key 的并发请求合并成一次加载，见 do
+func (g *Group) load(ctx context.Context, key string) (ByteView, error) {
+	return g.do(ctx, key, func(ctx context.Context) (ByteView, error) {
 		if g.peers != nil {
-			if peer, ok := g.peers.PickPeer(key); ok {
-				if value, err = g.getFromPeer(peer, key); err == nil {
+			if peer, ok := g.peers.PickPeer(key); ok && g.breaker.allow(peer) {
+				version := g.Version()
+				value, err := g.getFromPeer(ctx, peer, key)
+				if ctx.Err() != nil {
+					// 分组已经销毁，不算节点失败，也不再回退到本地加载
+					g.breaker.abort(peer)
+					return ByteView{}, ctx.Err()
+				}
+				if errors.Is(err, ErrNotFound) {
+					// 归属节点正常工作，只是 key 不存在，不必再问数据源
+					g.breaker.done(peer, nil)
+					g.negative.add(key, err)
+					return ByteView{}, err
+				}
+				g.breaker.done(peer, err)
+				if err == nil {
+					value.version = version
+					g.populateHotCache(key, value)
 					return value, nil
 				}
 				log.Println("[GeeCache] Failed to get from peer", err)
 			}
 		}
-		return g.getLocally(key)
+		return g.getLocally(ctx, key)
 	})
-	if err == nil {
-		return viewi.(ByteView), nil
+}
+
+// do 用 singleflight 合并同一个 key 的加载。加载用 detach 后的 ctx，
+// 不会因为某个调用方取消而失败；调用方的 ctx 只决定它自己等多久
+func (g *Group) do(ctx context.Context, key string, fn func(ctx context.Context) (ByteView, error)) (ByteView, error) {
+	if err := ctx.Err(); err != nil {
+		return ByteView{}, err
 	}
-	return
+	loadCtx := g.detach(ctx)
+	select {
+	case res := <-g.loader.DoChan(key, func() (interface{}, error) { return fn(loadCtx) }):
+		if res.Err != nil {
+			return ByteView{}, res.Err
+		}
+		return res.Val.(ByteView), nil
+	case <-ctx.Done():
+		return ByteView{}, ctx.Err()
+	}
+}
+
+// detach 返回保留 ctx 的值、但只在分组销毁时取消的 context
+func (g *Group) detach(ctx context.Context) context.Context {
+	return detachedContext{parent: ctx, done: g.done}
 }
 
+type detachedContext struct {
+	parent context.Context
+	done   <-chan struct{}
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+
+func (c detachedContext) Done() <-chan struct{} { return c.done }
+
+func (c detachedContext) Err() error {
+	select {
+	case <-c.done:
+		return context.Canceled
+	default:
+		return nil
+	}
+}
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
 // 找不到的话调用load-再调用getLocally
-func (g *Group) getLocally(key string) (ByteView, error) {
-	bytes, err := g.getter.Get(key)
+func (g *Group) getLocally(ctx context.Context, key string) (ByteView, error) {
+	return g.getLocallyWith(ctx, key, g.getter)
+}
+
+func (g *Group) getLocallyWith(ctx context.Context, key string, getter GetterCtx) (ByteView, error) {
+	value, err := g.loadWith(ctx, key, getter)
 	if err != nil {
 		return ByteView{}, err
-
 	}
-	value := ByteView{b: cloneBytes(bytes)}
 	// 将这个值添加到缓存中
 	g.populateCache(key, value)
 	return value, nil
 }
 
+// loadWith 用 getter 从数据源加载 key，不写缓存
+func (g *Group) loadWith(ctx context.Context, key string, getter GetterCtx) (ByteView, error) {
+	// 加载期间版本可能被提升，用开始加载时的版本标记这个值
+	version := g.Version()
+	release, err := g.acquireLoad(ctx)
+	if err != nil {
+		return ByteView{}, err
+	}
+	start := time.Now()
+	bytes, expire, err := getWithExpire(ctx, getter, key)
+	release()
+	count(err, &g.stats.localLoads, &g.stats.loadErrors)
+	g.emit(g.hooks.OnLoad, Event{Key: key, Bytes: len(bytes), Duration: time.Since(start), Err: err})
+	if err != nil {
+		g.emit(g.hooks.OnLoadError, Event{Key: key, Duration: time.Since(start), Err: err})
+		g.negative.add(key, err)
+		return ByteView{}, err
+
+	}
+	// 数据源没有给出过期时间时使用分组的 TTL
+	if expire.IsZero() {
+		expire = g.expiry(g.ttl)
+	}
+	return ByteView{b: cloneBytes(bytes), e: expire, version: version}, nil
+}
+
+// Set stores value under key in the local cache, e.g. right after the
+// application wrote it to the database. A ttl of 0 falls back to the
+// group's TTL, a negative ttl keeps the value until it is evicted. With
+// WithKeyFilter the key is also added to the filter of every peer.
+func (g *Group) Set(key string, value ByteView, ttl time.Duration) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if g.oversized(value) {
+		return fmt.Errorf("value of %d bytes exceeds the max value size %d", value.Len(), g.maxValueSize)
+	}
+	if ttl == 0 {
+		ttl = g.ttl
+	}
+	value.e = g.expiry(ttl)
+	value.version = g.Version()
+	g.negative.remove(key)
+	g.populateCache(key, value)
+	return g.addKey(key)
+}
+
+// Remove deletes key from the owning peer and the local cache, so the next
+// Get loads it again. With WithBroadcastRemove the other peers are
+// invalidated too.
+func (g *Group) Remove(ctx context.Context, key string) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	// 先删归属节点，否则本地删除后可能马上又从归属节点取回旧值
+	var owner PeerGetter
+	if g.peers != nil {
+		if peer, ok := g.peers.PickPeer(key); ok {
+			owner = peer
+			if err := peer.Remove(ctx, g.name, key); err != nil {
+				return err
+			}
+		}
+	}
+	g.removeLocally(key)
+	if !g.broadcastRemove || g.peers == nil {
+		return nil
+	}
+
+	return g.forEachPeer(owner, func(peer PeerGetter) error {
+		if err := peer.Remove(ctx, g.name, key); err != nil {
+			return fmt.Errorf("remove %s from %s: %w", key, peerName(peer), err)
+		}
+		return nil
+	})
+}
+
+// Clear drops every entry of the group from the local cache at once, e.g.
+// after a bulk data migration.
+func (g *Group) Clear() {
+	g.mainCache.clear()
+	g.hotCache.clear()
+	g.negative.clear()
+}
+
+// ClearAll clears the group locally and on every peer.
+func (g *Group) ClearAll(ctx context.Context) error {
+	g.Clear()
+	if g.peers == nil {
+		return nil
+	}
+	return g.forEachPeer(nil, func(peer PeerGetter) error {
+		if err := peer.Clear(ctx, g.name); err != nil {
+			return fmt.Errorf("clear %s on %s: %w", g.name, peerName(peer), err)
+		}
+		return nil
+	})
+}
+
+// forEachPeer 并发地对除 skip 以外的所有节点执行 fn，返回遇到的第一个错误
+func (g *Group) forEachPeer(skip PeerGetter, fn func(PeerGetter) error) error {
+	peers := g.peers.GetAll()
+	var wg sync.WaitGroup
+	errs := make(chan error, len(peers))
+	for _, peer := range peers {
+		if peer == skip {
+			continue
+		}
+		wg.Add(1)
+		go func(peer PeerGetter) {
+			defer wg.Done()
+			if err := fn(peer); err != nil {
+				errs <- err
+			}
+		}(peer)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// removeLocally 处理其他节点发来的失效请求，只删除本地缓存
+func (g *Group) removeLocally(key string) {
+	g.mainCache.remove(key)
+	g.hotCache.remove(key)
+	g.negative.remove(key)
+}
+
+// lookupCache 依次查找 mainCache 和 hotCache，命中过期或快过期的值时安排后台刷新。
+// 旧版本的值当作未命中，留给淘汰回收
+func (g *Group) lookupCache(key string) (ByteView, bool) {
+	v, ok := g.mainCache.get(key)
+	hot := false
+	if !ok {
+		v, ok = g.hotCache.get(key)
+		hot = ok
+	}
+	if !ok || v.version != g.Version() {
+		return ByteView{}, false
+	}
+	g.maybeRefresh(key, v, hot)
+	return v, true
+}
+
 func (g *Group) populateCache(key string, value ByteView) {
+	if g.oversized(value) {
+		return
+	}
 	g.mainCache.add(key, value)
 }
 
-func (g *Group) getFromPeer(peer PeerGetter, key string) (ByteView, error) {
-	bytes, err := peer.Get(g.name, key)
+func (g *Group) getFromPeer(ctx context.Context, peer PeerGetter, key string) (ByteView, error) {
+	start := time.Now()
+	bytes, expire, err := getFromPeerWithExpire(ctx, peer, g.name, key)
+	count(err, &g.stats.peerLoads, &g.stats.peerErrors)
+	g.emit(g.hooks.OnPeerFetch, Event{Key: key, Bytes: len(bytes), Duration: time.Since(start), Peer: peerName(peer), Err: err})
 	if err != nil {
 		return ByteView{}, err
 	}
-	return ByteView{b: bytes}, nil
+	return ByteView{b: bytes, e: expire}, nil
+}
+
+// getFromPeerWithExpire 在节点支持时一起取回归属节点上的过期时间
+func getFromPeerWithExpire(ctx context.Context, peer PeerGetter, group string, key string) ([]byte, time.Time, error) {
+	if tp, ok := peer.(PeerGetterWithTTL); ok {
+		return tp.GetWithTTL(ctx, group, key)
+	}
+	b, err := peer.Get(ctx, group, key)
+	return b, time.Time{}, err
 }
 
 // RegisterPeers registers a PeerPicker for choosing remote peer
@@ -1,10 +1,15 @@
 package geecache
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestGetter(t *testing.T) {
@@ -40,15 +45,168 @@ func TestGet(t *testing.T) {
 		}))
 
 	for k, v := range db {
-		if view, err := gee.Get(k); err != nil || view.String() != v {
+		if view, err := gee.Get(context.Background(), k); err != nil || view.String() != v {
 			t.Fatal("failed to get value of Tom")
 		} // load from callback function
-		if _, err := gee.Get(k); err != nil || loadCounts[k] > 1 {
+		if _, err := gee.Get(context.Background(), k); err != nil || loadCounts[k] > 1 {
 			t.Fatalf("cache %s miss", k)
 		} // cache hit
 	}
 
-	if view, err := gee.Get("unknown"); err == nil {
+	if view, err := gee.Get(context.Background(), "unknown"); err == nil {
 		t.Fatalf("the value of unknow should be empty, but %s got", view)
 	}
 }
+
+func TestGetterCtxCanceled(t *testing.T) {
+	type ctxKey struct{}
+	started, release := make(chan struct{}), make(chan struct{})
+	var loadErr error
+	var loadValue interface{}
+	g := NewGroupContext("slowdb", 2<<10, GetterCtxFunc(func(ctx context.Context, key string) ([]byte, error) {
+		close(started)
+		<-release
+		loadErr, loadValue = ctx.Err(), ctx.Value(ctxKey{})
+		return []byte(key), nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "first"))
+	first := make(chan error)
+	go func() {
+		_, err := g.Get(ctx, "Tom")
+		first <- err
+	}()
+	<-started
+	second := make(chan ByteView)
+	go func() {
+		v, _ := g.Get(context.Background(), "Tom")
+		second <- v
+	}()
+
+	// 第一个调用方放弃后立刻返回，合并在一起的加载继续为其他调用方执行
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	close(release)
+	if v := <-second; v.String() != "Tom" {
+		t.Fatalf("other callers should get the value, got %q", v)
+	}
+	if loadErr != nil || loadValue != "first" {
+		t.Fatalf("load should keep the values but not the cancellation of the first ctx, got %v, %v", loadErr, loadValue)
+	}
+}
+
+func TestReplaceGroup(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	})
+	old := NewGroup("replaced", 2<<10, getter, WithTTL(time.Minute))
+	defer DestroyGroup("replaced")
+	old.Get(context.Background(), "Tom")
+
+	g := NewGroup("replaced", 2<<10, getter, WithTTL(time.Minute))
+	if GetGroup("replaced") != g {
+		t.Fatalf("the new group should be registered")
+	}
+	select {
+	case <-old.done:
+	default:
+		t.Fatalf("the replaced group should stop its sweeper")
+	}
+	if _, ok := old.mainCache.get("Tom"); ok {
+		t.Fatalf("the replaced group should release its cache")
+	}
+}
+
+func TestDestroyGroupCancelsLoads(t *testing.T) {
+	g := NewGroupContext("slowdb-destroy", 2<<10, GetterCtxFunc(func(ctx context.Context, key string) ([]byte, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}))
+	errc := make(chan error)
+	go func() {
+		_, err := g.Get(context.Background(), "Tom")
+		errc <- err
+	}()
+	DestroyGroup("slowdb-destroy")
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("destroying the group should cancel its loads, got %v", err)
+	}
+}
+
+func TestDestroyGroup(t *testing.T) {
+	g := NewGroup("tenant-1", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithTTL(time.Minute))
+	g.Get(context.Background(), "Tom")
+
+	DestroyGroup("tenant-1")
+	if GetGroup("tenant-1") != nil {
+		t.Fatalf("destroyed group should be deregistered")
+	}
+	for _, s := range g.mainCache.shards {
+		if s.store != nil {
+			t.Fatalf("destroyed group should release its cache")
+		}
+	}
+	select {
+	case <-g.done:
+	default:
+		t.Fatalf("destroyed group should stop its sweeper")
+	}
+
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+	res, err := http.Get(srv.URL + defaultBasePath + "tenant-1/Tom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatalf("peer request for a destroyed group should be 404, got %d", res.StatusCode)
+	}
+	DestroyGroup("tenant-1")
+}
+
+func TestGetOrLoad(t *testing.T) {
+	g := NewGroup("get-or-load", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("default"), nil
+	}))
+	ctx := context.Background()
+	token := "secret"
+	loader := GetterCtxFunc(func(ctx context.Context, key string) ([]byte, error) {
+		return []byte(key + " loaded with " + token), nil
+	})
+
+	if v, err := g.GetOrLoad(ctx, "Tom", loader); err != nil || v.String() != "Tom loaded with secret" {
+		t.Fatalf("GetOrLoad should use the given loader, got %q %v", v, err)
+	}
+	if v, _ := g.Get(ctx, "Tom"); v.String() != "Tom loaded with secret" {
+		t.Fatalf("value from GetOrLoad should be cached, got %q", v)
+	}
+	if v, _ := g.GetOrLoad(ctx, "Jack", nil); v.String() != "default" {
+		t.Fatalf("nil loader should fall back to the Getter, got %q", v)
+	}
+}
+
+func TestGetOrLoadRemoteKey(t *testing.T) {
+	owner := &fakePeer{name: "owner"}
+	g := NewGroup("get-or-load-remote", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("default"), nil
+	}), WithHotCache(1<<10, 1, time.Minute))
+	g.RegisterPeers(&fakePicker{owner: owner})
+	loader := GetterCtxFunc(func(ctx context.Context, key string) ([]byte, error) {
+		return []byte(key + " loaded"), nil
+	})
+
+	if v, err := g.GetOrLoad(context.Background(), "Tom", loader); err != nil || v.String() != "Tom loaded" {
+		t.Fatalf("GetOrLoad should use the given loader, got %q %v", v, err)
+	}
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatalf("keys owned by a peer should not go into mainCache")
+	}
+	if v, ok := g.hotCache.get("Tom"); !ok || v.Expire().IsZero() {
+		t.Fatalf("keys owned by a peer should only be kept in the hot cache with a TTL")
+	}
+}
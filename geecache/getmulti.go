@@ -0,0 +1,236 @@
+package geecache
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// maxMultiKeys 是一次发给其他节点或 BatchGetter 的 key 数上限，更多的 key 分批发送
+	maxMultiKeys = 1000
+	// maxMultiLoads 是一次 GetMulti 同时进行的请求或加载数
+	maxMultiLoads = 16
+)
+
+// BatchGetter can be implemented by a Getter or GetterCtx that loads many
+// keys from the origin in one query. Keys it can't load are left out of the
+// result.
+type BatchGetter interface {
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error)
+}
+
+// GetMulti looks up several keys at once, e.g. for a list page. Cached keys
+// are answered locally, the misses are fetched from the owning peers
+// concurrently and in batches from the origin when the Getter implements
+// BatchGetter. Batches hold at most 1000 keys and at most 16 requests or
+// loads run at a time. Keys that fail to load are missing from the result and the
+// first error is returned alongside the values that were found.
+func (g *Group) GetMulti(ctx context.Context, keys []string) (map[string]ByteView, error) {
+	res := make(map[string]ByteView, len(keys))
+	var misses []string
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		atomic.AddInt64(&g.stats.gets, 1)
+		if v, ok := g.lookupCache(key); ok {
+			atomic.AddInt64(&g.stats.hits, 1)
+			g.emit(g.hooks.OnHit, Event{Key: key, Bytes: v.Len()})
+			res[key] = v
+			continue
+		}
+		atomic.AddInt64(&g.stats.misses, 1)
+		g.emit(g.hooks.OnMiss, Event{Key: key})
+		if !g.mayExist(key) || g.negative.get(key) != nil {
+			continue
+		}
+		misses = append(misses, key)
+	}
+
+	// 按归属节点分组并发请求；请求失败的 key 回退到本地加载
+	local := misses
+	if g.peers != nil {
+		local = nil
+		byPeer := make(map[PeerGetter][]string)
+		for _, key := range misses {
+			if peer, ok := g.peers.PickPeer(key); ok {
+				byPeer[peer] = append(byPeer[peer], key)
+			} else {
+				local = append(local, key)
+			}
+		}
+		var (
+			wg  sync.WaitGroup
+			mu  sync.Mutex
+			sem = make(chan struct{}, maxMultiLoads)
+		)
+		for peer, keys := range byPeer {
+			for _, batch := range batches(keys, maxMultiKeys) {
+				wg.Add(1)
+				sem <- struct{}{}
+				go func(peer PeerGetter, batch []string) {
+					defer wg.Done()
+					defer func() { <-sem }()
+					found, rest := g.getMultiFromPeer(ctx, peer, batch)
+					mu.Lock()
+					defer mu.Unlock()
+					for key, v := range found {
+						res[key] = v
+					}
+					local = append(local, rest...)
+				}(peer, batch)
+			}
+		}
+		wg.Wait()
+	}
+	if len(local) == 0 {
+		return res, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return res, err
+	}
+	return res, g.getMultiLocally(ctx, local, res)
+}
+
+// getMultiFromPeer 返回取到的值和请求失败、需要本地加载的 key。节点响应里没有的
+// key 和单个 Get 收到 404 一样当作不存在，不再问数据源
+func (g *Group) getMultiFromPeer(ctx context.Context, peer PeerGetter, keys []string) (map[string]ByteView, []string) {
+	if _, ok := peer.(PeerMultiGetter); !ok || !g.breaker.allow(peer) {
+		return nil, keys
+	}
+	version := g.Version()
+	start := time.Now()
+	values, expires, err := getMultiFromPeerWithExpire(ctx, peer.(PeerMultiGetter), g.name, keys)
+	if ctx.Err() != nil {
+		// 调用方已经放弃，不算节点失败
+		g.breaker.abort(peer)
+		return nil, keys
+	}
+	g.breaker.done(peer, err)
+	g.emit(g.hooks.OnPeerFetch, Event{Bytes: len(values), Duration: time.Since(start), Peer: peerName(peer), Err: err})
+	if err != nil {
+		atomic.AddInt64(&g.stats.peerErrors, 1)
+		log.Println("[GeeCache] Failed to get from peer", err)
+		return nil, keys
+	}
+	found := make(map[string]ByteView, len(values))
+	for _, key := range keys {
+		b, ok := values[key]
+		if !ok {
+			g.negative.add(key, ErrNotFound)
+			continue
+		}
+		atomic.AddInt64(&g.stats.peerLoads, 1)
+		value := ByteView{b: b, e: expires[key], version: version}
+		g.populateHotCache(key, value)
+		found[key] = value
+	}
+	return found, nil
+}
+
+// getMultiFromPeerWithExpire 在节点支持时一起取回每个 key 的过期时间
+func getMultiFromPeerWithExpire(ctx context.Context, mg PeerMultiGetter, group string, keys []string) (map[string][]byte, map[string]time.Time, error) {
+	if tg, ok := mg.(PeerMultiGetterWithTTL); ok {
+		return tg.GetMultiWithTTL(ctx, group, keys)
+	}
+	values, err := mg.GetMulti(ctx, group, keys)
+	return values, nil, err
+}
+
+// getMultiLocally 优先用 BatchGetter 分批查询，否则并发地逐个加载
+func (g *Group) getMultiLocally(ctx context.Context, keys []string, res map[string]ByteView) error {
+	if bg, ok := g.batchGetter(); ok {
+		for _, batch := range batches(keys, maxMultiKeys) {
+			if err := g.getBatchLocally(ctx, bg, batch, res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		sem      = make(chan struct{}, maxMultiLoads)
+	)
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			v, err := g.do(ctx, key, func(ctx context.Context) (ByteView, error) {
+				return g.getLocally(ctx, key)
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			res[key] = v
+		}(key)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// getBatchLocally 用 BatchGetter 一次查询一批 key
+func (g *Group) getBatchLocally(ctx context.Context, bg BatchGetter, keys []string, res map[string]ByteView) error {
+	version := g.Version()
+	release, err := g.acquireLoad(ctx)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	values, err := bg.GetMulti(ctx, keys)
+	release()
+	g.emit(g.hooks.OnLoad, Event{Bytes: len(values), Duration: time.Since(start), Err: err})
+	if err != nil {
+		atomic.AddInt64(&g.stats.loadErrors, 1)
+		return err
+	}
+	for _, key := range keys {
+		b, ok := values[key]
+		if !ok {
+			// BatchGetter 结果里没有的 key 就是不存在
+			g.negative.add(key, ErrNotFound)
+			continue
+		}
+		atomic.AddInt64(&g.stats.localLoads, 1)
+		value := ByteView{b: cloneBytes(b), e: g.expiry(g.ttl), version: version}
+		g.populateCache(key, value)
+		res[key] = value
+	}
+	return nil
+}
+
+// batches 把 keys 按 n 个一批切开
+func batches(keys []string, n int) [][]string {
+	var bs [][]string
+	for len(keys) > n {
+		bs = append(bs, keys[:n])
+		keys = keys[n:]
+	}
+	if len(keys) > 0 {
+		bs = append(bs, keys)
+	}
+	return bs
+}
+
+func (g *Group) batchGetter() (BatchGetter, bool) {
+	if a, ok := g.getter.(getterAdapter); ok {
+		bg, ok := a.getter.(BatchGetter)
+		return bg, ok
+	}
+	bg, ok := g.getter.(BatchGetter)
+	return bg, ok
+}
@@ -0,0 +1,285 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type batchDB struct {
+	batches [][]string
+}
+
+func (db *batchDB) Get(key string) ([]byte, error) {
+	return nil, fmt.Errorf("single Get should not be used")
+}
+
+func (db *batchDB) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	db.batches = append(db.batches, sorted)
+	values := make(map[string][]byte)
+	for _, key := range keys {
+		if key != "missing" {
+			values[key] = []byte("v-" + key)
+		}
+	}
+	return values, nil
+}
+
+func TestGetMultiBatchGetter(t *testing.T) {
+	db := &batchDB{}
+	g := NewGroup("multi", 2<<10, db)
+	g.Set("a", NewByteView([]byte("cached")), 0)
+
+	res, err := g.GetMulti(context.Background(), []string{"a", "b", "c", "b", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": "cached", "b": "v-b", "c": "v-c"}
+	got := make(map[string]string)
+	for k, v := range res {
+		got[k] = v.String()
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("GetMulti = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(db.batches, [][]string{{"b", "c", "missing"}}) {
+		t.Fatalf("misses should be loaded in one batch, got %v", db.batches)
+	}
+	if _, ok := g.mainCache.get("c"); !ok {
+		t.Fatalf("batch-loaded values should be cached")
+	}
+}
+
+// multiPeer 是支持批量获取的 fakePeer
+type multiPeer struct {
+	fakePeer
+	calls int
+}
+
+func (p *multiPeer) GetMulti(ctx context.Context, group string, keys []string) (map[string][]byte, error) {
+	p.calls++
+	values := make(map[string][]byte)
+	for _, key := range keys {
+		values[key] = []byte(p.name + "-" + key)
+	}
+	return values, nil
+}
+
+type multiPicker struct {
+	peer *multiPeer
+}
+
+func (p *multiPicker) PickPeer(key string) (PeerGetter, bool) {
+	return p.peer, key != "mine"
+}
+
+func (p *multiPicker) GetAll() []PeerGetter {
+	return []PeerGetter{p.peer}
+}
+
+func TestGetMultiPeers(t *testing.T) {
+	peer := &multiPeer{fakePeer: fakePeer{name: "p"}}
+	g := NewGroup("multi-peers", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local-" + key), nil
+	}))
+	g.RegisterPeers(&multiPicker{peer: peer})
+
+	res, err := g.GetMulti(context.Background(), []string{"x", "y", "mine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res["x"].String() != "p-x" || res["y"].String() != "p-y" || res["mine"].String() != "local-mine" {
+		t.Fatalf("unexpected result %v", res)
+	}
+	if peer.calls != 1 || peer.gets != 0 {
+		t.Fatalf("keys owned by a peer should be fetched in one request, calls = %d", peer.calls)
+	}
+}
+
+func TestHTTPGetMulti(t *testing.T) {
+	NewGroup("http-multi", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if key == "missing" {
+			return nil, ErrNotFound
+		}
+		return []byte(key + "!"), nil
+	}))
+	srv, peer := newPeerServer()
+	defer srv.Close()
+
+	values, err := peer.GetMulti(context.Background(), "http-multi", []string{"Tom", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || string(values["Tom"]) != "Tom!" {
+		t.Fatalf("unexpected values %q", values)
+	}
+}
+
+// sparsePeer 对 missing 单独获取时返回 ErrNotFound，批量获取时不返回它
+type sparsePeer struct {
+	multiPeer
+}
+
+func (p *sparsePeer) Get(ctx context.Context, group string, key string) ([]byte, error) {
+	if key == "missing" {
+		return nil, ErrNotFound
+	}
+	return p.multiPeer.Get(ctx, group, key)
+}
+
+func (p *sparsePeer) GetMulti(ctx context.Context, group string, keys []string) (map[string][]byte, error) {
+	values, err := p.multiPeer.GetMulti(ctx, group, keys)
+	delete(values, "missing")
+	return values, err
+}
+
+func TestGetMultiPeerNotFound(t *testing.T) {
+	var loads int32
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		atomic.AddInt32(&loads, 1)
+		return []byte("local-" + key), nil
+	})
+	peer := &sparsePeer{multiPeer{fakePeer: fakePeer{name: "p"}}}
+	single := NewGroup("multi-not-found-get", 2<<10, getter, WithNegativeCache(time.Minute, false))
+	single.RegisterPeers(singlePicker{peer: peer})
+	multi := NewGroup("multi-not-found", 2<<10, getter, WithNegativeCache(time.Minute, false))
+	multi.RegisterPeers(singlePicker{peer: peer})
+
+	if _, err := single.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get: expected ErrNotFound, got %v", err)
+	}
+	res, err := multi.GetMulti(context.Background(), []string{"x", "missing"})
+	if err != nil || len(res) != 1 || res["x"].String() != "p-x" {
+		t.Fatalf("GetMulti = %v, %v", res, err)
+	}
+	if loads != 0 {
+		t.Fatalf("keys the owner doesn't have should not be loaded locally, loads = %d", loads)
+	}
+	// 和 Get 一样，不存在的结果被缓存
+	for _, g := range []*Group{single, multi} {
+		if err := g.negative.get("missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("%s: missing should be negatively cached, got %v", g.name, err)
+		}
+	}
+}
+
+func TestGetMultiBatches(t *testing.T) {
+	db := &batchDB{}
+	g := NewGroup("multi-batches", 2<<20, db)
+	keys := make([]string, 2500)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i)
+	}
+	if res, err := g.GetMulti(context.Background(), keys); err != nil || len(res) != len(keys) {
+		t.Fatalf("GetMulti = %d values, %v", len(res), err)
+	}
+	var sizes []int
+	for _, b := range db.batches {
+		sizes = append(sizes, len(b))
+	}
+	if !reflect.DeepEqual(sizes, []int{maxMultiKeys, maxMultiKeys, 500}) {
+		t.Fatalf("misses should be loaded in batches of %d, got %v", maxMultiKeys, sizes)
+	}
+}
+
+func TestGetMultiConcurrency(t *testing.T) {
+	var running, peak int32
+	g := NewGroup("multi-concurrency", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return []byte(key), nil
+	}))
+	keys := make([]string, 4*maxMultiLoads)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i)
+	}
+	if res, err := g.GetMulti(context.Background(), keys); err != nil || len(res) != len(keys) {
+		t.Fatalf("GetMulti = %d values, %v", len(res), err)
+	}
+	if peak > maxMultiLoads {
+		t.Fatalf("at most %d keys should load at once, got %d", maxMultiLoads, peak)
+	}
+}
+
+func TestHTTPGetMultiTooManyKeys(t *testing.T) {
+	NewGroup("http-multi-cap", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	srv, peer := newPeerServer()
+	defer srv.Close()
+
+	keys := make([]string, maxMultiKeys+1)
+	for i := range keys {
+		keys[i] = fmt.Sprint(i)
+	}
+	if _, err := peer.GetMulti(context.Background(), "http-multi-cap", keys); err == nil {
+		t.Fatalf("a batch of more than %d keys should be refused", maxMultiKeys)
+	}
+}
+
+// blockingPeer 的 GetMulti 等到 release 关闭才返回
+type blockingPeer struct {
+	fakePeer
+	arrived chan<- struct{}
+	release <-chan struct{}
+}
+
+func (p *blockingPeer) GetMulti(ctx context.Context, group string, keys []string) (map[string][]byte, error) {
+	p.arrived <- struct{}{}
+	<-p.release
+	values := make(map[string][]byte)
+	for _, key := range keys {
+		values[key] = []byte(p.name)
+	}
+	return values, nil
+}
+
+type keyPicker map[string]PeerGetter
+
+func (p keyPicker) PickPeer(key string) (PeerGetter, bool) {
+	peer, ok := p[key]
+	return peer, ok
+}
+
+func (p keyPicker) GetAll() []PeerGetter { return nil }
+
+func TestGetMultiPeersConcurrently(t *testing.T) {
+	arrived, release := make(chan struct{}), make(chan struct{})
+	a := &blockingPeer{fakePeer: fakePeer{name: "a"}, arrived: arrived, release: release}
+	b := &blockingPeer{fakePeer: fakePeer{name: "b"}, arrived: arrived, release: release}
+	g := NewGroup("multi-fanout", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local"), nil
+	}))
+	g.RegisterPeers(keyPicker{"x": a, "y": b})
+
+	done := make(chan map[string]ByteView)
+	go func() {
+		res, _ := g.GetMulti(context.Background(), []string{"x", "y"})
+		done <- res
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case <-arrived:
+		case <-time.After(time.Second):
+			t.Fatalf("peers should be asked concurrently")
+		}
+	}
+	close(release)
+	if res := <-done; res["x"].String() != "a" || res["y"].String() != "b" {
+		t.Fatalf("unexpected result %v", res)
+	}
+}
@@ -0,0 +1,55 @@
+package geecache
+
+import (
+	"sort"
+	"time"
+)
+
+// GroupInfo describes the configuration and counters of a group.
+type GroupInfo struct {
+	Name          string        `json:"name"`
+	CacheBytes    int64         `json:"cache_bytes"`
+	HotCacheBytes int64         `json:"hot_cache_bytes"`
+	TTL           time.Duration `json:"ttl"`
+	MaxValueSize  int           `json:"max_value_size"`
+	Version       int64         `json:"version"`
+	Stats         Stats         `json:"stats"`
+}
+
+// Groups returns every registered group sorted by name, so admin pages and
+// metrics exporters can enumerate them.
+func Groups() []GroupInfo {
+	mu.RLock()
+	list := make([]*Group, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	mu.RUnlock()
+
+	infos := make([]GroupInfo, len(list))
+	for i, g := range list {
+		infos[i] = g.Info()
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Info returns the group's configuration and current Stats.
+func (g *Group) Info() GroupInfo {
+	return GroupInfo{
+		Name:          g.name,
+		CacheBytes:    g.mainCache.cacheBytes,
+		HotCacheBytes: g.hotCache.cacheBytes,
+		TTL:           g.ttl,
+		MaxValueSize:  g.maxValueSize,
+		Version:       g.Version(),
+		Stats:         g.Stats(),
+	}
+}
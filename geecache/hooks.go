@@ -0,0 +1,47 @@
+package geecache
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event carries the details of one cache event passed to Hooks.
+type Event struct {
+	Group    string
+	Key      string
+	Bytes    int
+	Duration time.Duration
+	// Peer 只在 OnPeerFetch 和 OnRemoveFailed 中有值，是远程节点的地址
+	Peer string
+	Err  error
+}
+
+// Hooks lets applications feed their own metrics or tracing systems. Nil
+// fields are skipped. Hooks run synchronously on the request path, so they
+// should be cheap.
+type Hooks struct {
+	OnHit       func(Event)
+	OnMiss      func(Event)
+	OnLoad      func(Event) // 调用本地 Getter 结束后触发，失败时 Err 不为空
+	OnLoadError func(Event) // 调用本地 Getter 失败时在 OnLoad 之后触发
+	OnPeerFetch func(Event) // 从远程节点获取结束后触发，失败时 Err 不为空
+	OnEvict     func(Event) // 条目因为空间不足被淘汰后触发，Remove 和 Clear 不触发
+	// OnRemoveFailed 在 RemoveMulti 重试后仍没能让节点删除 key 时对每个 key 触发，
+	// 可以记下来稍后重新失效
+	OnRemoveFailed func(Event)
+}
+
+func (g *Group) emit(fn func(Event), e Event) {
+	if fn == nil {
+		return
+	}
+	e.Group = g.name
+	fn(e)
+}
+
+func peerName(peer PeerGetter) string {
+	if s, ok := peer.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", peer)
+}
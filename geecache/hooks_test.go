@@ -0,0 +1,61 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	var events []string
+	record := func(kind string) func(Event) {
+		return func(e Event) {
+			if e.Group != "hooks" {
+				t.Errorf("event should carry the group name, got %q", e.Group)
+			}
+			events = append(events, kind+":"+e.Key)
+		}
+	}
+	// 两种 store 的容量都只够放两个条目
+	stores := map[string]struct {
+		fn       StoreFunc
+		maxBytes int64
+	}{
+		"lru":  {NewLRUStore, 2 * (2 + 4)},
+		"slab": {NewSlabStore(1), 2 * (slabHeaderSize + 2 + 4)},
+	}
+	for name, store := range stores {
+		events = nil
+		g := NewGroup("hooks", store.maxBytes, GetterFunc(func(key string) ([]byte, error) {
+			if key == "k0" {
+				return nil, fmt.Errorf("%s not exist", key)
+			}
+			return []byte("1234"), nil
+		}), WithStore(store.fn), WithHooks(Hooks{
+			OnHit:       record("hit"),
+			OnMiss:      record("miss"),
+			OnLoad:      record("load"),
+			OnLoadError: record("error"),
+			OnEvict:     record("evict"),
+		}))
+		ctx := context.Background()
+		g.Get(ctx, "k0")
+		g.Get(ctx, "k1")
+		g.Get(ctx, "k1")
+		g.Get(ctx, "k2")
+		g.Get(ctx, "k3")
+		g.Remove(ctx, "k3")
+
+		want := []string{
+			"miss:k0", "load:k0", "error:k0",
+			"miss:k1", "load:k1",
+			"hit:k1",
+			"miss:k2", "load:k2",
+			"miss:k3", "load:k3", "evict:k1",
+		}
+		if !reflect.DeepEqual(events, want) {
+			t.Fatalf("%s: events = %v, want %v", name, events, want)
+		}
+	}
+}
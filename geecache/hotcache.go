@@ -0,0 +1,55 @@
+package geecache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// 和 groupcache 一样，从远程节点取回的值默认有 1/10 的概率放进热点缓存
+const defaultHotCacheOneIn = 10
+
+// WithHotCache turns on the hot cache, which keeps local copies of values
+// owned by other peers, with a byte budget of maxBytes. A value fetched
+// from a peer is admitted with a probability of 1/oneIn so only frequently
+// requested keys stay. Invalidations sent by Group.Remove only reach the
+// owner unless WithBroadcastRemove is set, and Set never reaches other
+// nodes, so copies live at most ttl, or less when the owner's value
+// expires earlier. The hot cache is off by default; a maxBytes of 0 keeps
+// it off, and a positive maxBytes with a ttl <= 0 panics.
+func WithHotCache(maxBytes int64, oneIn int, ttl time.Duration) GroupOption {
+	if maxBytes > 0 && ttl <= 0 {
+		panic("geecache: hot cache needs a positive ttl")
+	}
+	return func(g *Group) {
+		g.hotCache.cacheBytes = maxBytes
+		g.hotOneIn = oneIn
+		g.hotTTL = ttl
+	}
+}
+
+// admitHot 决定这次从远程节点取回的值是否放进热点缓存
+func (g *Group) admitHot() bool {
+	if g.hotCache.cacheBytes <= 0 {
+		return false
+	}
+	return g.hotOneIn <= 1 || rand.Intn(g.hotOneIn) == 0
+}
+
+// populateHotCache 按概率把远程节点取回的值放进热点缓存，
+// 副本最多存活 hotTTL，归属节点给出的过期时间更早时以它为准
+func (g *Group) populateHotCache(key string, value ByteView) {
+	if g.admitHot() {
+		g.addHot(key, value)
+	}
+}
+
+// addHot 把值放进热点缓存，副本的过期时间按 populateHotCache 的规则截短
+func (g *Group) addHot(key string, value ByteView) {
+	if g.oversized(value) {
+		return
+	}
+	if e := g.expiry(g.hotTTL); value.e.IsZero() || e.Before(value.e) {
+		value.e = e
+	}
+	g.hotCache.add(key, value)
+}
@@ -0,0 +1,116 @@
+package geecache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestHotCache(t *testing.T) {
+	owner := &fakePeer{name: "owner"}
+	g := NewGroup("hot", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local"), nil
+	}), WithHotCache(1<<10, 1, time.Minute))
+	g.RegisterPeers(&fakePicker{owner: owner, all: []*fakePeer{owner}})
+
+	for i := 0; i < 3; i++ {
+		if v, err := g.Get(context.Background(), "Tom"); err != nil || v.String() != "owner" {
+			t.Fatalf("Get should return the owner's value, got %q", v)
+		}
+	}
+	if owner.gets != 1 {
+		t.Fatalf("hot key should be served locally after the first fetch, peer gets = %d", owner.gets)
+	}
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatalf("values owned by a peer should not go into mainCache")
+	}
+
+	g.removeLocally("Tom")
+	g.Get(context.Background(), "Tom")
+	if owner.gets != 2 {
+		t.Fatalf("invalidation should drop the hot copy, peer gets = %d", owner.gets)
+	}
+}
+
+func TestHotCacheDisabled(t *testing.T) {
+	owner := &fakePeer{name: "owner"}
+	// 不设置 WithHotCache 时热点缓存是关闭的
+	g := NewGroup("hot-disabled", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local"), nil
+	}))
+	g.RegisterPeers(&fakePicker{owner: owner, all: []*fakePeer{owner}})
+
+	g.Get(context.Background(), "Tom")
+	g.Get(context.Background(), "Tom")
+	if owner.gets != 2 {
+		t.Fatalf("disabled hot cache should not keep copies, peer gets = %d", owner.gets)
+	}
+}
+
+// ttlPeer 是会报告过期时间的 fakePeer
+type ttlPeer struct {
+	fakePeer
+	expire time.Time
+}
+
+func (p *ttlPeer) GetWithTTL(ctx context.Context, group string, key string) ([]byte, time.Time, error) {
+	b, err := p.Get(ctx, group, key)
+	return b, p.expire, err
+}
+
+type ttlPicker struct {
+	peer *ttlPeer
+}
+
+func (p *ttlPicker) PickPeer(key string) (PeerGetter, bool) {
+	return p.peer, true
+}
+
+func (p *ttlPicker) GetAll() []PeerGetter {
+	return []PeerGetter{p.peer}
+}
+
+func TestHotCacheExpiry(t *testing.T) {
+	owner := &ttlPeer{fakePeer: fakePeer{name: "owner"}, expire: time.Now().Add(time.Second)}
+	g := NewGroup("hot-expiry", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local"), nil
+	}), WithHotCache(1<<10, 1, time.Minute))
+	g.RegisterPeers(&ttlPicker{peer: owner})
+
+	g.Get(context.Background(), "Tom")
+	if v, ok := g.hotCache.get("Tom"); !ok || !v.Expire().Equal(owner.expire) {
+		t.Fatalf("hot copy should expire with the owner's value at %v, got %v", owner.expire, v.Expire())
+	}
+
+	owner.expire = time.Time{}
+	g.Get(context.Background(), "Jack")
+	if v, ok := g.hotCache.get("Jack"); !ok || v.Expire().IsZero() || v.Expire().After(time.Now().Add(time.Minute)) {
+		t.Fatalf("hot copy should live at most the hot TTL, expires at %v", v.Expire())
+	}
+}
+
+func TestHotCacheNeedsTTL(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("WithHotCache without a ttl should panic")
+		}
+	}()
+	WithHotCache(1<<10, 1, 0)
+}
+
+func TestHTTPGetWithTTL(t *testing.T) {
+	expire := time.Now().Add(time.Hour).Round(0)
+	NewGroupContext("http-ttl", 2<<10, GetterWithTTLFunc(func(ctx context.Context, key string) ([]byte, time.Time, error) {
+		return []byte(key), expire, nil
+	}))
+	srv, peer := newPeerServer()
+	defer srv.Close()
+
+	if _, e, err := peer.GetWithTTL(context.Background(), "http-ttl", "Tom"); err != nil || !e.Equal(expire) {
+		t.Fatalf("GetWithTTL = %v, %v, want expiry %v", e, err, expire)
+	}
+	_, expires, err := peer.GetMultiWithTTL(context.Background(), "http-ttl", []string{"Jack"})
+	if err != nil || !expires["Jack"].Equal(expire) {
+		t.Fatalf("GetMultiWithTTL = %v, %v, want expiry %v", expires, err, expire)
+	}
+}
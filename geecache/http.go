@@ -1,19 +1,30 @@
 package geecache
 
 import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"geecache/consistenthash"
 	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
 	defaultBasePath = "/_geecache/"
 	defaultReplicas = 50
+	// maxMultiBody 是批量获取请求体的上限，足够放下 maxMultiKeys 个普通长度的 key
+	maxMultiBody = 1 << 20
+	// expireHeader 是 GET 响应里值在归属节点上的过期时间，Unix 纳秒
+	expireHeader = "X-Geecache-Expire"
 )
 
 // HTTP缓存池
@@ -26,10 +37,16 @@ type HTTPPool struct {
 	// 新增成员变量 peers，类型是一致性哈希算法的 Map，用来根据具体的 key 选择节点。
 	peers       *consistenthash.Map
 	httpGetters map[string]*httpGetter // keyed by e.g. "http://10.0.0.2:8008"
+	// adminToken 不为空时开启管理接口，见 EnableAdmin
+	adminToken string
+	// peerSecret 是节点之间修改缓存的请求携带的共享密钥，见 SetPeerSecret
+	peerSecret string
 }
 
 type httpGetter struct {
 	baseURL string
+	// secret 不为空时放在 Authorization 头里发给其他节点
+	secret string
 }
 
 func NewHTTPPool(self string) *HTTPPool {
@@ -52,25 +69,59 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// 记录请求方法和路径
 	p.Log("%s %s", r.Method, r.URL.Path)
 
+	if route := r.URL.Path[len(p.basePath):]; p.adminToken != "" && strings.HasPrefix(route, adminPrefix) {
+		p.serveAdmin(w, r, route[len(adminPrefix):])
+		return
+	}
+
 	// 从请求路径中解析出组名（groupName）和键名（key）。
 	parts := strings.SplitN(r.URL.Path[len(p.basePath):], "/", 2)
 
 	// 如果解析后的路径部分数量不为2，返回"bad request"和HTTP状态码400。
-	if len(parts) != 2 {
+	// 只有针对整个分组的请求没有 key：DELETE 清空分组或批量失效，POST 批量获取，PUT 设置版本
+	whole := len(parts) == 1 && (r.Method == http.MethodDelete || r.Method == http.MethodPost || r.Method == http.MethodPut)
+	if len(parts) != 2 && !whole {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
+	// 只有 GET 是公开的，其余请求会修改缓存或者一次加载很多 key，先验证身份再查找分组
+	if r.Method != http.MethodGet && !p.authorizePeer(w, r) {
+		return
+	}
 	groupName := parts[0]
-	key := parts[1]
 
 	group := GetGroup(groupName)
 	if group == nil {
 		http.Error(w, "no suck group: "+groupName, http.StatusNotFound)
 		return
 	}
+	if whole {
+		p.serveGroup(w, r, group)
+		return
+	}
+	key := parts[1]
+
+	// DELETE 是其他节点发来的失效请求
+	if r.Method == http.MethodDelete {
+		group.removeLocally(key)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// PUT 是其他节点 Set 之后发来的过滤器添加请求
+	if r.Method == http.MethodPut {
+		if group.keyFilter != nil {
+			group.keyFilter.Add(key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
 
-	// 通过组的Get方法获取缓存项（view），如果获取失败则返回错误信息和HTTP状态码500。
-	view, err := group.Get(key)
+	// 通过组的Get方法获取缓存项（view），key 不存在时返回 404，其他错误返回 500。
+	view, err := group.Get(r.Context(), key)
+	if errors.Is(err, ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -78,9 +129,119 @@ func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	// 设置响应头的"Content-Type"为"application/octet-stream"，表示响应内容是二进制流。
 	w.Header().Set("Content-Type", "application/octet-stream")
+	if !view.e.IsZero() {
+		w.Header().Set(expireHeader, strconv.FormatInt(view.e.UnixNano(), 10))
+	}
 	w.Write(view.ByteSlice())
 }
 
+func (p *HTTPPool) serveGroup(w http.ResponseWriter, r *http.Request, group *Group) {
+	switch r.Method {
+	case http.MethodDelete:
+		// 带 key 列表的是批量失效请求，没有请求体的是清空整个分组
+		if r.ContentLength == 0 {
+			group.Clear()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var keys []string
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMultiBody)).Decode(&keys); err != nil {
+			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(keys) > maxMultiKeys {
+			http.Error(w, "too many keys", http.StatusRequestEntityTooLarge)
+			return
+		}
+		for _, key := range keys {
+			group.removeLocally(key)
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case http.MethodPut:
+		version, err := strconv.ParseInt(r.URL.Query().Get("version"), 10, 64)
+		if err != nil {
+			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := group.setVersion(version); err != nil {
+			http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	// 批量获取：请求体是 key 的 JSON 数组，响应见 multiResponse
+	var keys []string
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxMultiBody)).Decode(&keys); err != nil {
+		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(keys) > maxMultiKeys {
+		http.Error(w, "too many keys", http.StatusRequestEntityTooLarge)
+		return
+	}
+	views, err := group.GetMulti(r.Context(), keys)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		// 响应里缺的 key 只表示不存在，其他加载错误让整批失败，请求方会回退到本地加载
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res := multiResponse{Values: make(map[string][]byte, len(views))}
+	for key, view := range views {
+		res.Values[key] = view.b
+		if !view.e.IsZero() {
+			if res.Expires == nil {
+				res.Expires = make(map[string]int64)
+			}
+			res.Expires[key] = view.e.UnixNano()
+		}
+	}
+	writeJSON(w, res)
+}
+
+// multiResponse 是批量获取的响应，不存在的 key 不出现，永不过期的 key 没有过期时间
+type multiResponse struct {
+	Values  map[string][]byte `json:"values"`
+	Expires map[string]int64  `json:"expires,omitempty"` // Unix 纳秒
+}
+
+// SetPeerSecret sets the secret shared by all nodes. Every peer request
+// except GET, i.e. the invalidations sent by Group.Remove and ClearAll,
+// version bumps and batch lookups, carries it as
+// "Authorization: Bearer <secret>" and is refused without it; the admin
+// token is accepted too. Without a secret those requests are always
+// refused, so every node must be given the same one.
+func (p *HTTPPool) SetPeerSecret(secret string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.peerSecret = secret
+	// 换成新的 httpGetter，正在发送的请求还用着旧的，不需要加锁
+	for peer, getter := range p.httpGetters {
+		p.httpGetters[peer] = &httpGetter{baseURL: getter.baseURL, secret: secret}
+	}
+}
+
+// authorizePeer 检查修改缓存的请求是否带了节点密钥或管理令牌，失败时写好 401 响应
+func (p *HTTPPool) authorizePeer(w http.ResponseWriter, r *http.Request) bool {
+	p.mu.Lock()
+	secret := p.peerSecret
+	p.mu.Unlock()
+	if bearerMatches(r, secret) || bearerMatches(r, p.adminToken) {
+		return true
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="geecache"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+// bearerMatches 比较请求的 Bearer 令牌，token 为空时总是不匹配
+func bearerMatches(r *http.Request, token string) bool {
+	auth := r.Header.Get("Authorization")
+	return token != "" && strings.HasPrefix(auth, "Bearer ") &&
+		subtle.ConstantTimeCompare([]byte(auth[len("Bearer "):]), []byte(token)) == 1
+}
+
 // 实例化了一致性哈希算法，并且添加了传入的节点。并为每一个节点创建了一个 HTTP 客户端 httpGetter。
 func (p *HTTPPool) Set(peers ...string) {
 	p.mu.Lock()
@@ -89,7 +250,7 @@ func (p *HTTPPool) Set(peers ...string) {
 	p.peers.Add(peers...)
 	p.httpGetters = make(map[string]*httpGetter, len(peers))
 	for _, peer := range peers {
-		p.httpGetters[peer] = &httpGetter{baseURL: peer + p.basePath}
+		p.httpGetters[peer] = &httpGetter{baseURL: peer + p.basePath, secret: p.peerSecret}
 	}
 }
 
@@ -105,33 +266,179 @@ func (p *HTTPPool) PickPeer(key string) (PeerGetter, bool) {
 	return nil, false
 }
 
+// GetAll returns the clients of all peers except self.
+func (p *HTTPPool) GetAll() []PeerGetter {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var getters []PeerGetter
+	for peer, getter := range p.httpGetters {
+		if peer != p.self {
+			getters = append(getters, getter)
+		}
+	}
+	return getters
+}
+
 var _ PeerPicker = (*HTTPPool)(nil)
 
-func (h *httpGetter) Get(group string, key string) ([]byte, error) {
+func (h *httpGetter) String() string {
+	return h.baseURL
+}
+
+func (h *httpGetter) Get(ctx context.Context, group string, key string) ([]byte, error) {
+	b, _, err := h.GetWithTTL(ctx, group, key)
+	return b, err
+}
+
+func (h *httpGetter) GetWithTTL(ctx context.Context, group string, key string) ([]byte, time.Time, error) {
 	u := fmt.Sprintf(
 		"%v%v/%v",
 		h.baseURL,
 		url.QueryEscape(group),
 		url.QueryEscape(key),
 	)
-	res, err := http.Get(u)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return nil, err
+		return nil, time.Time{}, err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, time.Time{}, err
 	}
 
 	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, time.Time{}, ErrNotFound
+	}
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned: %v", res.Status)
+		return nil, time.Time{}, fmt.Errorf("server returned: %v", res.Status)
 	}
 
 	bytes, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %v", err)
+		return nil, time.Time{}, fmt.Errorf("reading response body: %v", err)
 	}
 
-	return bytes, nil
+	var expire time.Time
+	if e, err := strconv.ParseInt(res.Header.Get(expireHeader), 10, 64); err == nil {
+		expire = time.Unix(0, e)
+	}
+	return bytes, expire, nil
+}
+
+func (h *httpGetter) Remove(ctx context.Context, group string, key string) error {
+	u := fmt.Sprintf(
+		"%v%v/%v",
+		h.baseURL,
+		url.QueryEscape(group),
+		url.QueryEscape(key),
+	)
+	return h.do(ctx, http.MethodDelete, u)
+}
+
+func (h *httpGetter) AddKey(ctx context.Context, group string, key string) error {
+	u := fmt.Sprintf(
+		"%v%v/%v",
+		h.baseURL,
+		url.QueryEscape(group),
+		url.QueryEscape(key),
+	)
+	return h.do(ctx, http.MethodPut, u)
+}
+
+func (h *httpGetter) GetMulti(ctx context.Context, group string, keys []string) (map[string][]byte, error) {
+	values, _, err := h.GetMultiWithTTL(ctx, group, keys)
+	return values, err
+}
+
+func (h *httpGetter) GetMultiWithTTL(ctx context.Context, group string, keys []string) (map[string][]byte, map[string]time.Time, error) {
+	body, err := json.Marshal(keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	u := h.baseURL + url.QueryEscape(group)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	h.authorize(req)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("server returned: %v", res.Status)
+	}
+	var mr multiResponse
+	if err := json.NewDecoder(res.Body).Decode(&mr); err != nil {
+		return nil, nil, fmt.Errorf("decoding response body: %v", err)
+	}
+	expires := make(map[string]time.Time, len(mr.Expires))
+	for key, e := range mr.Expires {
+		expires[key] = time.Unix(0, e)
+	}
+	return mr.Values, expires, nil
+}
+
+func (h *httpGetter) RemoveMulti(ctx context.Context, group string, keys []string) error {
+	body, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	u := h.baseURL + url.QueryEscape(group)
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return h.send(req)
+}
+
+func (h *httpGetter) Clear(ctx context.Context, group string) error {
+	return h.do(ctx, http.MethodDelete, h.baseURL+url.QueryEscape(group))
+}
+
+func (h *httpGetter) SetVersion(ctx context.Context, group string, version int64) error {
+	return h.do(ctx, http.MethodPut, h.baseURL+url.QueryEscape(group)+"?version="+strconv.FormatInt(version, 10))
+}
+
+// do 发送不需要读取响应体的请求
+func (h *httpGetter) do(ctx context.Context, method string, u string) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return err
+	}
+	return h.send(req)
+}
+
+// send 带上节点密钥发送 req，只检查状态码
+func (h *httpGetter) send(req *http.Request) error {
+	h.authorize(req)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned: %v", res.Status)
+	}
+	return nil
+}
+
+// authorize 给请求带上节点密钥
+func (h *httpGetter) authorize(req *http.Request) {
+	if h.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+h.secret)
+	}
 }
 
 // var _ PeerGetter = (*httpGetter)(nil) 这行代码实际上是在静态检查编译时确认 httpGetter 类型是否实现了 PeerGetter 接口。如果 httpGetter 类型没有实现 PeerGetter 接口，编译器会在编译时报错。
 // 如果 httpGetter 类型实现了 PeerGetter 接口，这个声明将通过编译，否则会导致编译错误。
 var _ PeerGetter = (*httpGetter)(nil)
+var _ PeerMultiGetter = (*httpGetter)(nil)
+var _ PeerGetterWithTTL = (*httpGetter)(nil)
+var _ PeerMultiGetterWithTTL = (*httpGetter)(nil)
+var _ PeerKeyFilter = (*httpGetter)(nil)
+var _ PeerMultiRemover = (*httpGetter)(nil)
@@ -0,0 +1,157 @@
+// Package lfu implements a least frequently used cache with the same byte
+// accounting as package lru.
+package lfu
+
+import (
+	"container/list"
+
+	"geecache/lru"
+)
+
+// Value use Len to count how many bytes it takes
+type Value = lru.Value
+
+// lfu Cache, 并发访问不安全。
+// 访问次数相同的条目放在同一个桶里，桶按次数从小到大排成链表，
+// 所以 Get、Add 和淘汰都是 O(1)。
+type Cache struct {
+	maxBytes int64
+	nbytes   int64
+	// buckets 中每个元素是一个 *bucket，按 freq 升序排列
+	buckets *list.List
+	cache   map[string]*list.Element
+	// OnEvicted 在条目被淘汰或删除时调用
+	OnEvicted func(key string, value Value)
+}
+
+type bucket struct {
+	freq int
+	// items 中越靠前的条目越新，同一个桶里先淘汰最旧的
+	items *list.List
+}
+
+type entry struct {
+	key    string
+	value  Value
+	bucket *list.Element
+}
+
+func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
+	// 负数和 0 一样表示不限制，否则会一直淘汰到缓存为空
+	if maxBytes < 0 {
+		maxBytes = 0
+	}
+	return &Cache{
+		maxBytes:  maxBytes,
+		buckets:   list.New(),
+		cache:     make(map[string]*list.Element),
+		OnEvicted: onEvicted,
+	}
+}
+
+func (c *Cache) Get(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		c.touch(ele)
+		return ele.Value.(*entry).value, true
+	}
+	return
+}
+
+// Peek returns the value of key without counting as an access.
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		return ele.Value.(*entry).value, true
+	}
+	return
+}
+
+// touch 把条目移到访问次数加一的桶里
+func (c *Cache) touch(ele *list.Element) {
+	kv := ele.Value.(*entry)
+	cur := kv.bucket
+	b := cur.Value.(*bucket)
+	next := cur.Next()
+	if next == nil || next.Value.(*bucket).freq != b.freq+1 {
+		next = c.buckets.InsertAfter(&bucket{freq: b.freq + 1, items: list.New()}, cur)
+	}
+	b.items.Remove(ele)
+	kv.bucket = next
+	c.cache[kv.key] = next.Value.(*bucket).items.PushFront(kv)
+	if b.items.Len() == 0 {
+		c.buckets.Remove(cur)
+	}
+}
+
+// RemoveOldest removes the least frequently used entry, the oldest one if
+// several share the lowest count.
+func (c *Cache) RemoveOldest() {
+	if front := c.buckets.Front(); front != nil {
+		c.removeElement(front.Value.(*bucket).items.Back())
+	}
+}
+
+// Remove removes the provided key from the cache.
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		c.removeElement(ele)
+	}
+}
+
+func (c *Cache) removeElement(ele *list.Element) {
+	kv := ele.Value.(*entry)
+	b := kv.bucket.Value.(*bucket)
+	b.items.Remove(ele)
+	if b.items.Len() == 0 {
+		c.buckets.Remove(kv.bucket)
+	}
+	delete(c.cache, kv.key)
+	c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	if c.OnEvicted != nil {
+		c.OnEvicted(kv.key, kv.value)
+	}
+}
+
+// Range calls fn for each entry from the most to the least frequently
+// used, without counting as an access, until fn returns false. fn must not
+// modify the cache.
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for be := c.buckets.Back(); be != nil; be = be.Prev() {
+		for ele := be.Value.(*bucket).items.Front(); ele != nil; ele = ele.Next() {
+			kv := ele.Value.(*entry)
+			if !fn(kv.key, kv.value) {
+				return
+			}
+		}
+	}
+}
+
+// Add adds or updates key. Updating counts as an access; a new entry
+// starts with a count of one.
+func (c *Cache) Add(key string, value Value) {
+	if ele, ok := c.cache[key]; ok {
+		kv := ele.Value.(*entry)
+		c.nbytes += int64(value.Len()) - int64(kv.value.Len())
+		kv.value = value
+		c.touch(ele)
+	} else {
+		front := c.buckets.Front()
+		if front == nil || front.Value.(*bucket).freq != 1 {
+			front = c.buckets.PushFront(&bucket{freq: 1, items: list.New()})
+		}
+		kv := &entry{key: key, value: value, bucket: front}
+		c.cache[key] = front.Value.(*bucket).items.PushFront(kv)
+		c.nbytes += int64(len(key)) + int64(value.Len())
+	}
+	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+		c.RemoveOldest()
+	}
+}
+
+func (c *Cache) Len() int {
+	return len(c.cache)
+}
+
+// Bytes returns the number of bytes currently held, keys included.
+func (c *Cache) Bytes() int64 {
+	return c.nbytes
+}
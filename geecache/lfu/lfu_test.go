@@ -0,0 +1,72 @@
+package lfu
+
+import (
+	"reflect"
+	"testing"
+)
+
+type String string
+
+func (d String) Len() int {
+	return len(d)
+}
+
+func TestGet(t *testing.T) {
+	lfu := New(int64(0), nil)
+	lfu.Add("key1", String("1234"))
+	if v, ok := lfu.Get("key1"); !ok || string(v.(String)) != "1234" {
+		t.Fatalf("cache hit key1=1234 failed")
+	}
+	if _, ok := lfu.Get("key2"); ok {
+		t.Fatalf("cache miss key2 failed")
+	}
+}
+
+func TestNegativeLimit(t *testing.T) {
+	lfu := New(int64(-1), nil)
+	lfu.Add("key1", String("1"))
+	lfu.Add("key2", String("2"))
+	if lfu.Len() != 2 {
+		t.Fatalf("a negative limit should mean no limit, got %d entries", lfu.Len())
+	}
+}
+
+func TestEvictLeastFrequent(t *testing.T) {
+	var evicted []string
+	lfu := New(int64(3*4), func(key string, value Value) {
+		evicted = append(evicted, key)
+	})
+	lfu.Add("k1", String("v1"))
+	lfu.Add("k2", String("v2"))
+	lfu.Add("k3", String("v3"))
+	lfu.Get("k1")
+	lfu.Get("k1")
+	lfu.Get("k3")
+
+	// k2 只被访问过一次，最先淘汰；之后新加入的 k4 次数最少
+	lfu.Add("k4", String("v4"))
+	lfu.Add("k5", String("v5"))
+	if expect := []string{"k2", "k4"}; !reflect.DeepEqual(evicted, expect) {
+		t.Fatalf("evicted %v, expect %v", evicted, expect)
+	}
+
+	var keys []string
+	lfu.Range(func(key string, value Value) bool {
+		keys = append(keys, key)
+		return true
+	})
+	if expect := []string{"k1", "k3", "k5"}; !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("Range got %v, expect %v", keys, expect)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	lfu := New(int64(0), nil)
+	lfu.Add("key1", String("1234"))
+	lfu.Add("key2", String("5678"))
+	lfu.Get("key1")
+	lfu.Remove("key1")
+	if _, ok := lfu.Get("key1"); ok || lfu.Len() != 1 || lfu.Bytes() != int64(len("key2")+4) {
+		t.Fatalf("Remove key1 failed")
+	}
+}
@@ -0,0 +1,51 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTooManyLoads is returned when a group created WithLoadLimit can't
+// start another getter call in time.
+var ErrTooManyLoads = errors.New("geecache: too many concurrent loads")
+
+// WithLoadLimit caps the number of getter calls of the group running at
+// once, on top of singleflight merging calls for the same key, to protect
+// the origin during a cold start. A load beyond the cap waits up to wait
+// for a free slot, or fails right away with ErrTooManyLoads when wait is 0.
+func WithLoadLimit(n int, wait time.Duration) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.loadSlots = make(chan struct{}, n)
+			g.loadWait = wait
+		}
+	}
+}
+
+// acquireLoad 占用一个加载名额，返回的函数用来释放
+func (g *Group) acquireLoad(ctx context.Context) (release func(), err error) {
+	if g.loadSlots == nil {
+		return func() {}, nil
+	}
+	release = func() { <-g.loadSlots }
+	select {
+	case g.loadSlots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if g.loadWait > 0 {
+		timer := time.NewTimer(g.loadWait)
+		defer timer.Stop()
+		select {
+		case g.loadSlots <- struct{}{}:
+			return release, nil
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	atomic.AddInt64(&g.stats.shedLoads, 1)
+	return nil, ErrTooManyLoads
+}
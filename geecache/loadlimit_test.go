@@ -0,0 +1,73 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLoadLimit(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	g := NewGroup("loadlimit", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if key == "slow" {
+			close(started)
+			<-unblock
+		}
+		return []byte(key), nil
+	}), WithLoadLimit(1, 0))
+	ctx := context.Background()
+
+	done := make(chan error)
+	go func() {
+		_, err := g.Get(ctx, "slow")
+		done <- err
+	}()
+	<-started
+	if _, err := g.Get(ctx, "other"); !errors.Is(err, ErrTooManyLoads) {
+		t.Fatalf("expected ErrTooManyLoads, got %v", err)
+	}
+	if s := g.Stats(); s.ShedLoads != 1 {
+		t.Fatalf("ShedLoads = %d, want 1", s.ShedLoads)
+	}
+	close(unblock)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// 名额释放后可以继续加载，被拒绝的结果也不会缓存
+	if v, err := g.Get(ctx, "other"); err != nil || v.String() != "other" {
+		t.Fatalf("got %q %v after the slot was freed", v, err)
+	}
+}
+
+func TestLoadLimitWait(t *testing.T) {
+	unblock := make(chan struct{})
+	started := make(chan struct{})
+	g := NewGroup("loadlimit-wait", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if key == "slow" {
+			close(started)
+			<-unblock
+		}
+		return []byte(key), nil
+	}), WithLoadLimit(1, 50*time.Millisecond))
+	ctx := context.Background()
+
+	go g.Get(ctx, "slow")
+	<-started
+	begin := time.Now()
+	if _, err := g.Get(ctx, "timeout"); !errors.Is(err, ErrTooManyLoads) {
+		t.Fatalf("expected ErrTooManyLoads, got %v", err)
+	}
+	if d := time.Since(begin); d < 50*time.Millisecond {
+		t.Fatalf("returned after %v, should wait for a slot", d)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(unblock)
+	}()
+	if v, err := g.Get(ctx, "waited"); err != nil || v.String() != "waited" {
+		t.Fatalf("got %q %v, should get the freed slot", v, err)
+	}
+}
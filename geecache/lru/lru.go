@@ -1,6 +1,9 @@
 package lru
 
-import "container/list"
+import (
+	"container/list"
+	"time"
+)
 
 // lru Cache, 并发访问不安全
 type Cache struct {
@@ -13,11 +16,21 @@ type Cache struct {
 	// 当缓存中的某个键值对因为LRU（Least Recently Used，最近最少使用）策略被移除时，OnEvicted 函数会被调用，并传递被淘汰的键和值作为参数。
 	// 用户可以通过设置 OnEvicted 字段为自己的函数来定义在缓存淘汰时应该执行的操作，例如释放资源、记录日志等。
 	OnEvicted func(key string, value Value)
+	// MaxEntries 限制条目数，0 或负数表示不限制。大量很小的值即使没用完字节预算，
+	// map 和链表的开销也会很大
+	MaxEntries int
 }
 
 type entry struct {
 	key   string
 	value Value
+	// expire 为零值时永不过期
+	expire time.Time
+}
+
+// expired 判断条目在 now 时是否已经过期
+func (kv *entry) expired(now time.Time) bool {
+	return !kv.expire.IsZero() && now.After(kv.expire)
 }
 
 // Value use Len to count how many bytes it takes
@@ -34,48 +47,185 @@ func New(maxBytes int64, onEvicted func(string, Value)) *Cache {
 	}
 }
 
+// Get returns the value of key and marks it as the most recently used.
+// An expired entry is removed and reported as absent.
 func (c *Cache) Get(key string) (value Value, ok bool) {
 	if ele, ok := c.cache[key]; ok {
-		c.ll.MoveToFront(ele)
 		kv := ele.Value.(*entry)
+		if kv.expired(time.Now()) {
+			c.removeElement(ele)
+			return nil, false
+		}
+		c.ll.MoveToFront(ele)
 		return kv.value, true
 	}
 	return
 }
 
+// Peek returns the value of key without moving it to the front, so
+// inspecting the cache doesn't change what gets evicted next.
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		// 过期的条目当作不存在，但 Peek 不做删除
+		if kv := ele.Value.(*entry); !kv.expired(time.Now()) {
+			return kv.value, true
+		}
+	}
+	return
+}
+
+// Contains reports whether key is in the cache without updating its
+// recency.
+func (c *Cache) Contains(key string) bool {
+	_, ok := c.Peek(key)
+	return ok
+}
+
+// Keys returns the keys from the most to the least recently used.
+func (c *Cache) Keys() []string {
+	keys := make([]string, 0, c.ll.Len())
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+		keys = append(keys, ele.Value.(*entry).key)
+	}
+	return keys
+}
+
 func (c *Cache) RemoveOldest() {
 	// 取到队首节点，从链表中删除
 	ele := c.ll.Back()
 	if ele != nil {
-		c.ll.Remove(ele)
+		c.removeElement(ele)
+	}
+}
+
+// GetOldest returns the least recently used entry, the next one to be
+// evicted, without updating its recency.
+func (c *Cache) GetOldest() (key string, value Value, ok bool) {
+	if ele := c.ll.Back(); ele != nil {
+		kv := ele.Value.(*entry)
+		return kv.key, kv.value, true
+	}
+	return
+}
+
+// Remove removes the provided key from the cache.
+func (c *Cache) Remove(key string) {
+	if ele, ok := c.cache[key]; ok {
+		c.removeElement(ele)
+	}
+}
+
+func (c *Cache) removeElement(ele *list.Element) {
+	c.ll.Remove(ele)
+	kv := ele.Value.(*entry)
+	// 从字典中 c.cache 删除该节点的映射关系
+	delete(c.cache, kv.key)
+	c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	if c.OnEvicted != nil {
+		c.OnEvicted(kv.key, kv.value)
+	}
+}
+
+// Range calls fn for each entry from the most to the least recently used,
+// without changing their order, until fn returns false. fn must not modify
+// the cache.
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
 		kv := ele.Value.(*entry)
-		// 从字典中 c.cache 删除该节点的映射关系
-		delete(c.cache, kv.key)
-		c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
-		if c.OnEvicted != nil {
-			c.OnEvicted(kv.key, kv.value)
+		if !fn(kv.key, kv.value) {
+			return
 		}
 	}
 }
 
 func (c *Cache) Add(key string, value Value) {
+	c.AddWithExpire(key, value, time.Time{})
+}
+
+// AddWithExpire adds or updates key like Add. The entry is treated as
+// absent after expire; a zero expire never expires. Expired entries are
+// deleted lazily by Get, or all at once by ExpireScan.
+func (c *Cache) AddWithExpire(key string, value Value, expire time.Time) {
 	// key存在，直接更新对应节点的值，并将节点移到最尾
 	if ele, ok := c.cache[key]; ok {
 		c.ll.MoveToFront(ele)
 		kv := ele.Value.(*entry)
 		c.nbytes += int64(value.Len()) - int64(kv.value.Len())
 		kv.value = value
+		kv.expire = expire
 	} else {
 		// 不存在的话添加新节点
-		ele := c.ll.PushFront(&entry{key, value})
+		ele := c.ll.PushFront(&entry{key, value, expire})
 		c.cache[key] = ele
 		c.nbytes += int64(len(key)) + int64(value.Len())
 	}
-	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+	for c.overLimit() {
+		c.RemoveOldest()
+	}
+}
+
+// Purge removes all entries and calls OnEvicted for each of them, from
+// the least to the most recently used.
+func (c *Cache) Purge() {
+	ll := c.ll
+	c.Reset()
+	if c.OnEvicted == nil {
+		return
+	}
+	// 先清空再回调，回调里可以安全地访问缓存
+	for ele := ll.Back(); ele != nil; ele = ele.Prev() {
+		kv := ele.Value.(*entry)
+		c.OnEvicted(kv.key, kv.value)
+	}
+}
+
+// Reset removes all entries like Purge, without calling OnEvicted.
+func (c *Cache) Reset() {
+	c.ll = list.New()
+	c.cache = make(map[string]*list.Element)
+	c.nbytes = 0
+}
+
+// ExpireScan removes all entries expired at now, calling OnEvicted for
+// each, and returns how many were removed.
+func (c *Cache) ExpireScan(now time.Time) int {
+	n := 0
+	for ele := c.ll.Back(); ele != nil; {
+		prev := ele.Prev()
+		if ele.Value.(*entry).expired(now) {
+			c.removeElement(ele)
+			n++
+		}
+		ele = prev
+	}
+	return n
+}
+
+// Resize changes the byte limit, 0 or less meaning no limit, and evicts the least
+// recently used entries until the cache fits both the byte and the entry
+// limit. It returns how many entries were evicted.
+func (c *Cache) Resize(maxBytes int64) int {
+	c.maxBytes = maxBytes
+	evicted := 0
+	for c.overLimit() {
 		c.RemoveOldest()
+		evicted++
 	}
+	return evicted
+}
+
+// overLimit 判断是否超过了字节数或条目数的限制
+func (c *Cache) overLimit() bool {
+	// 负数和 0 一样表示不限制，否则会一直淘汰到缓存为空
+	return (c.maxBytes > 0 && c.maxBytes < c.nbytes) ||
+		(c.MaxEntries > 0 && c.MaxEntries < c.ll.Len())
 }
 
 func (c *Cache) Len() int {
 	return c.ll.Len()
 }
+
+// Bytes returns the number of bytes currently held, keys included.
+func (c *Cache) Bytes() int64 {
+	return c.nbytes
+}
@@ -3,6 +3,7 @@ package lru
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 type String string
@@ -53,3 +54,176 @@ func TestOnEvicted(t *testing.T) {
 		t.Fatalf("Call OnEvicted failed, expect keys equals to %s", expect)
 	}
 }
+
+func TestRemove(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Add("key1", String("1234"))
+	lru.Add("key2", String("5678"))
+	lru.Remove("key1")
+	if _, ok := lru.Get("key1"); ok || lru.Len() != 1 || lru.Bytes() != int64(len("key2")+4) {
+		t.Fatalf("Remove key1 failed")
+	}
+}
+
+func TestGetOldest(t *testing.T) {
+	lru := New(int64(0), nil)
+	if _, _, ok := lru.GetOldest(); ok {
+		t.Fatalf("empty cache should have no oldest entry")
+	}
+	lru.Add("key1", String("1234"))
+	lru.Add("key2", String("5678"))
+	if key, _, ok := lru.GetOldest(); !ok || key != "key1" {
+		t.Fatalf("oldest should be key1, got %s", key)
+	}
+	lru.Get("key1")
+	if key, _, _ := lru.GetOldest(); key != "key2" {
+		t.Fatalf("oldest should be key2 after key1 is used, got %s", key)
+	}
+}
+
+func TestRemoveOnEvicted(t *testing.T) {
+	var keys []string
+	lru := New(int64(0), func(key string, value Value) {
+		keys = append(keys, key)
+	})
+	lru.Add("key1", String("1234"))
+	lru.Remove("key1")
+	lru.Remove("missing")
+	if !reflect.DeepEqual(keys, []string{"key1"}) || lru.Bytes() != 0 {
+		t.Fatalf("Remove should fire OnEvicted once for key1, got %v", keys)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Add("key1", String("1234"))
+	lru.Add("key2", String("5678"))
+	if v, ok := lru.Peek("key1"); !ok || string(v.(String)) != "1234" {
+		t.Fatalf("Peek key1=1234 failed")
+	}
+	if key, _, _ := lru.GetOldest(); key != "key1" {
+		t.Fatalf("Peek should not move key1 to the front")
+	}
+	if _, ok := lru.Peek("key3"); ok {
+		t.Fatalf("Peek miss key3 failed")
+	}
+}
+
+func TestContainsAndKeys(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Add("key1", String("1"))
+	lru.Add("key2", String("2"))
+	lru.Add("key3", String("3"))
+	if !lru.Contains("key1") || lru.Contains("key4") {
+		t.Fatalf("Contains failed")
+	}
+	// Contains 不改变顺序，Get 会把 key1 移到最前
+	if keys := lru.Keys(); !reflect.DeepEqual(keys, []string{"key3", "key2", "key1"}) {
+		t.Fatalf("Keys got %v", keys)
+	}
+	lru.Get("key1")
+	var keys []string
+	lru.Range(func(key string, value Value) bool {
+		keys = append(keys, key)
+		return len(keys) < 2
+	})
+	if !reflect.DeepEqual(keys, []string{"key1", "key3"}) {
+		t.Fatalf("Range got %v", keys)
+	}
+}
+
+func TestResize(t *testing.T) {
+	var keys []string
+	lru := New(int64(0), func(key string, value Value) {
+		keys = append(keys, key)
+	})
+	lru.Add("k1", String("v1"))
+	lru.Add("k2", String("v2"))
+	lru.Add("k3", String("v3"))
+	if n := lru.Resize(8); n != 1 || lru.Len() != 2 || !reflect.DeepEqual(keys, []string{"k1"}) {
+		t.Fatalf("shrinking should evict k1, evicted %d %v", n, keys)
+	}
+	if n := lru.Resize(12); n != 0 {
+		t.Fatalf("growing should not evict, evicted %d", n)
+	}
+	lru.Add("k4", String("v4"))
+	if lru.Len() != 3 || lru.Bytes() != 12 {
+		t.Fatalf("grown cache should hold 3 entries, got %d", lru.Len())
+	}
+	if n := lru.Resize(-1); n != 0 {
+		t.Fatalf("a negative limit should mean no limit, evicted %d", n)
+	}
+	lru.Add("k5", String("v5"))
+	if lru.Len() != 4 {
+		t.Fatalf("a negative limit should keep every entry, got %d", lru.Len())
+	}
+}
+
+func TestPurge(t *testing.T) {
+	var keys []string
+	lru := New(int64(0), func(key string, value Value) {
+		keys = append(keys, key)
+	})
+	lru.Add("k1", String("v1"))
+	lru.Add("k2", String("v2"))
+	lru.Purge()
+	if lru.Len() != 0 || lru.Bytes() != 0 || !reflect.DeepEqual(keys, []string{"k1", "k2"}) {
+		t.Fatalf("Purge should remove all entries and call OnEvicted, got %v", keys)
+	}
+
+	keys = nil
+	lru.Add("k3", String("v3"))
+	lru.Reset()
+	if lru.Len() != 0 || lru.Bytes() != 0 || keys != nil {
+		t.Fatalf("Reset should remove all entries without calling OnEvicted, got %v", keys)
+	}
+	if _, ok := lru.Get("k3"); ok {
+		t.Fatalf("k3 should be gone after Reset")
+	}
+}
+
+func TestExpire(t *testing.T) {
+	var keys []string
+	lru := New(int64(0), func(key string, value Value) {
+		keys = append(keys, key)
+	})
+	lru.AddWithExpire("old", String("1"), time.Now().Add(-time.Second))
+	lru.AddWithExpire("new", String("2"), time.Now().Add(time.Hour))
+	lru.AddWithExpire("gone", String("3"), time.Now().Add(-time.Second))
+	lru.Add("forever", String("4"))
+
+	if _, ok := lru.Peek("old"); ok || lru.Contains("old") || lru.Len() != 4 {
+		t.Fatalf("Peek and Contains should treat old as absent without removing it")
+	}
+	if _, ok := lru.Get("old"); ok || lru.Len() != 3 {
+		t.Fatalf("Get should remove the expired old")
+	}
+	if n := lru.ExpireScan(time.Now()); n != 1 || lru.Len() != 2 {
+		t.Fatalf("ExpireScan should remove gone, removed %d", n)
+	}
+	if !reflect.DeepEqual(keys, []string{"old", "gone"}) {
+		t.Fatalf("expired entries should be passed to OnEvicted, got %v", keys)
+	}
+	if n := lru.ExpireScan(time.Now().Add(2 * time.Hour)); n != 1 || !lru.Contains("forever") {
+		t.Fatalf("entries without expiry should never expire")
+	}
+}
+
+func TestMaxEntries(t *testing.T) {
+	var keys []string
+	lru := New(int64(1<<10), func(key string, value Value) {
+		keys = append(keys, key)
+	})
+	lru.MaxEntries = 2
+	lru.Add("k1", String("1"))
+	lru.Add("k2", String("2"))
+	lru.Add("k3", String("3"))
+	if lru.Len() != 2 || !reflect.DeepEqual(keys, []string{"k1"}) {
+		t.Fatalf("MaxEntries should evict k1 before the byte limit is reached, got %v", keys)
+	}
+	lru.MaxEntries = -1
+	lru.Add("k4", String("4"))
+	if lru.Len() != 3 {
+		t.Fatalf("a negative MaxEntries should mean no limit, got %d entries", lru.Len())
+	}
+}
@@ -0,0 +1,102 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"geecache/lru"
+	"sync"
+	"time"
+)
+
+// ErrNotFound should be returned, possibly wrapped, by getters when the key
+// doesn't exist at the origin. Groups created WithNegativeCache remember it
+// for a while instead of asking the getter again.
+var ErrNotFound = errors.New("geecache: key not found")
+
+// 负缓存只记 key，按 mainCache 的 1/16 分配字节预算，防止大量不存在的 key 占满内存
+const negativeCacheRatio = 16
+
+// WithNegativeCache caches ErrNotFound results for ttl, so repeated lookups
+// of missing keys don't reach the getter. With allErrors any getter error
+// is cached, except context cancellation and ErrTooManyLoads.
+func WithNegativeCache(ttl time.Duration, allErrors bool) GroupOption {
+	return func(g *Group) {
+		g.negative = &negativeCache{ttl: ttl, allErrors: allErrors}
+	}
+}
+
+type negativeCache struct {
+	mu        sync.Mutex
+	lru       *lru.Cache
+	maxBytes  int64
+	ttl       time.Duration
+	allErrors bool
+}
+
+type negativeEntry struct {
+	err    error
+	expire time.Time
+}
+
+// Len 只统计 key 的长度，lru 会把 key 也算进去
+func (e negativeEntry) Len() int {
+	return 0
+}
+
+func (n *negativeCache) init(cacheBytes int64) {
+	n.maxBytes = cacheBytes / negativeCacheRatio
+	// maxBytes 为 0 时 lru 不限制大小，只有 mainCache 本身不限制时才这样
+	if n.maxBytes == 0 && cacheBytes > 0 {
+		n.maxBytes = 1
+	}
+	n.lru = lru.New(n.maxBytes, nil)
+}
+
+// get 返回之前缓存的错误，没有或已过期时返回 nil
+func (n *negativeCache) get(key string) error {
+	if n == nil {
+		return nil
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	v, ok := n.lru.Get(key)
+	if !ok {
+		return nil
+	}
+	e := v.(negativeEntry)
+	if time.Now().After(e.expire) {
+		n.lru.Remove(key)
+		return nil
+	}
+	return e.err
+}
+
+func (n *negativeCache) add(key string, err error) {
+	if n == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrTooManyLoads) {
+		return
+	}
+	if !n.allErrors && !errors.Is(err, ErrNotFound) {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lru.Add(key, negativeEntry{err: err, expire: time.Now().Add(n.ttl)})
+}
+
+func (n *negativeCache) remove(key string) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lru.Remove(key)
+}
+
+func (n *negativeCache) clear() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.lru = lru.New(n.maxBytes, nil)
+}
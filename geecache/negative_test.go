@@ -0,0 +1,63 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNegativeCache(t *testing.T) {
+	loads := 0
+	g := NewGroup("negative", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		if key == "flaky" {
+			return nil, errors.New("db timeout")
+		}
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}), WithNegativeCache(30*time.Millisecond, false))
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := g.Get(ctx, "nobody"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("not-found should be cached, loads = %d", loads)
+	}
+
+	g.Get(ctx, "flaky")
+	g.Get(ctx, "flaky")
+	if loads != 3 {
+		t.Fatalf("other errors should not be cached by default, loads = %d", loads)
+	}
+
+	g.Set("nobody", NewByteView([]byte("now exists")), 0)
+	if v, err := g.Get(ctx, "nobody"); err != nil || v.String() != "now exists" {
+		t.Fatalf("Set should override a cached miss, got %q %v", v, err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	g.Get(ctx, "ghost")
+	time.Sleep(40 * time.Millisecond)
+	g.Get(ctx, "ghost")
+	if loads != 5 {
+		t.Fatalf("cached misses should expire, loads = %d", loads)
+	}
+}
+
+func TestNegativeCacheAllErrors(t *testing.T) {
+	loads := 0
+	g := NewGroup("negative-all", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return nil, errors.New("db timeout")
+	}), WithNegativeCache(time.Minute, true))
+
+	g.Get(context.Background(), "Tom")
+	g.Get(context.Background(), "Tom")
+	if loads != 1 {
+		t.Fatalf("errors should be cached with allErrors, loads = %d", loads)
+	}
+}
@@ -0,0 +1,83 @@
+package geecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// GroupOption configures a Group created by NewGroup.
+type GroupOption func(*Group)
+
+// WithStore replaces the default in-memory LRU behind the group's mainCache.
+func WithStore(fn StoreFunc) GroupOption {
+	return func(g *Group) {
+		g.mainCache.newStore = fn
+	}
+}
+
+// WithShards splits the group's main and hot caches into n shards by key
+// hash, each with its own lock and 1/n of the bytes, so concurrent requests
+// for different keys rarely wait on each other. Eviction then happens per
+// shard, not across the whole cache. Sharding is opt-in: without this
+// option each cache has a single shard.
+func WithShards(n int) GroupOption {
+	return func(g *Group) {
+		g.mainCache.nshards = n
+		g.hotCache.nshards = n
+	}
+}
+
+// WithPolicy makes the group's main cache evict with the policies fn
+// creates, e.g. a custom Policy, without touching the Group code.
+func WithPolicy(fn PolicyFunc) GroupOption {
+	return WithStore(PolicyStore(fn))
+}
+
+// WithPeerBreaker stops sending requests to a peer for coolDown after it
+// failed threshold times in a row; those keys are loaded locally instead.
+func WithPeerBreaker(threshold int, coolDown time.Duration) GroupOption {
+	return func(g *Group) {
+		g.breaker = newPeerBreaker(threshold, coolDown)
+	}
+}
+
+// WithHooks sets the observability hooks of the group.
+func WithHooks(hooks Hooks) GroupOption {
+	return func(g *Group) {
+		g.hooks = hooks
+		if hooks.OnEvict == nil {
+			return
+		}
+		onEvicted := func(key string, value ByteView) {
+			g.emit(g.hooks.OnEvict, Event{Key: key, Bytes: value.Len()})
+		}
+		g.mainCache.onEvicted = onEvicted
+		g.hotCache.onEvicted = onEvicted
+	}
+}
+
+// WithBroadcastRemove makes Group.Remove invalidate the key on every peer,
+// not only on the owner, for peers that may keep their own copies.
+func WithBroadcastRemove() GroupOption {
+	return func(g *Group) {
+		g.broadcastRemove = true
+	}
+}
+
+// WithMaxValueSize keeps values larger than n bytes out of the cache, so
+// one huge value can't evict the whole working set. Such values are still
+// returned to the caller and counted in Stats.Oversized.
+func WithMaxValueSize(n int) GroupOption {
+	return func(g *Group) {
+		g.maxValueSize = n
+	}
+}
+
+// oversized 报告 value 是否超过 maxValueSize，超过时计数
+func (g *Group) oversized(value ByteView) bool {
+	if g.maxValueSize <= 0 || value.Len() <= g.maxValueSize {
+		return false
+	}
+	atomic.AddInt64(&g.stats.oversized, 1)
+	return true
+}
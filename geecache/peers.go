@@ -1,9 +1,49 @@
 package geecache
 
+import (
+	"context"
+	"time"
+)
+
 type PeerPicker interface {
 	PickPeer(key string) (peer PeerGetter, ok bool)
+	// GetAll 返回除自己以外的所有节点，用于广播失效请求
+	GetAll() []PeerGetter
 }
 
 type PeerGetter interface {
-	Get(group string, key string) ([]byte, error)
+	// Get 在 key 不存在时返回 ErrNotFound，这时不会再回退到本地加载
+	Get(ctx context.Context, group string, key string) ([]byte, error)
+	// Remove 让远程节点删除本地缓存中的 key，不会再转发给其他节点
+	Remove(ctx context.Context, group string, key string) error
+	// Clear 让远程节点清空整个分组的本地缓存
+	Clear(ctx context.Context, group string) error
+	// SetVersion 把远程节点上分组的版本提升到 version
+	SetVersion(ctx context.Context, group string, version int64) error
+}
+
+// PeerMultiGetter is implemented by peers that can fetch several keys in
+// one round trip. Keys left out of the result don't exist; a peer that
+// fails to load a key returns an error instead.
+type PeerMultiGetter interface {
+	GetMulti(ctx context.Context, group string, keys []string) (map[string][]byte, error)
+}
+
+// PeerMultiRemover is implemented by peers that can invalidate several keys
+// in one round trip, see Group.RemoveMulti.
+type PeerMultiRemover interface {
+	RemoveMulti(ctx context.Context, group string, keys []string) error
+}
+
+// PeerGetterWithTTL is implemented by peers that also report when the
+// owner's value expires, so local copies don't outlive it. A zero expireAt
+// means the value never expires.
+type PeerGetterWithTTL interface {
+	GetWithTTL(ctx context.Context, group string, key string) (value []byte, expireAt time.Time, err error)
+}
+
+// PeerMultiGetterWithTTL is the PeerGetterWithTTL counterpart of
+// PeerMultiGetter. Keys missing from expireAt never expire.
+type PeerMultiGetterWithTTL interface {
+	GetMultiWithTTL(ctx context.Context, group string, keys []string) (values map[string][]byte, expireAt map[string]time.Time, err error)
 }
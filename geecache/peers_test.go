@@ -0,0 +1,235 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePeer 记录收到的失效请求
+type fakePeer struct {
+	name    string
+	mu      sync.Mutex
+	gets    int
+	removed []string
+}
+
+func (p *fakePeer) Get(ctx context.Context, group string, key string) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gets++
+	return []byte(p.name), nil
+}
+
+func (p *fakePeer) Remove(ctx context.Context, group string, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removed = append(p.removed, group+"/"+key)
+	return nil
+}
+
+func (p *fakePeer) Clear(ctx context.Context, group string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removed = append(p.removed, group+"/*")
+	return nil
+}
+
+func (p *fakePeer) SetVersion(ctx context.Context, group string, version int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.removed = append(p.removed, fmt.Sprintf("%s@%d", group, version))
+	return nil
+}
+
+type fakePicker struct {
+	owner *fakePeer
+	all   []*fakePeer
+}
+
+func (p *fakePicker) PickPeer(key string) (PeerGetter, bool) {
+	if p.owner == nil {
+		return nil, false
+	}
+	return p.owner, true
+}
+
+func (p *fakePicker) GetAll() []PeerGetter {
+	peers := make([]PeerGetter, len(p.all))
+	for i, peer := range p.all {
+		peers[i] = peer
+	}
+	return peers
+}
+
+func TestRemove(t *testing.T) {
+	a, b, c := &fakePeer{name: "a"}, &fakePeer{name: "b"}, &fakePeer{name: "c"}
+	g := NewGroup("remove", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithBroadcastRemove())
+	g.RegisterPeers(&fakePicker{owner: a, all: []*fakePeer{a, b, c}})
+	g.Set("Tom", NewByteView([]byte("630")), 0)
+
+	if err := g.Remove(context.Background(), "Tom"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatalf("Tom should be removed locally")
+	}
+	var got []string
+	for _, p := range []*fakePeer{a, b, c} {
+		got = append(got, p.removed...)
+	}
+	sort.Strings(got)
+	if want := []string{"remove/Tom", "remove/Tom", "remove/Tom"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("every peer should be invalidated once, got %v", got)
+	}
+}
+
+func TestHTTPPoolRemove(t *testing.T) {
+	g := NewGroup("http-remove", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	g.Set("Tom", NewByteView([]byte("630")), 0)
+	pool := NewHTTPPool("self")
+	pool.SetPeerSecret("s3cret")
+	srv := httptest.NewServer(pool)
+	defer srv.Close()
+
+	// 没有密钥或者密钥不对的请求不能删除
+	for _, secret := range []string{"", "wrong"} {
+		stranger := &httpGetter{baseURL: srv.URL + defaultBasePath, secret: secret}
+		if err := stranger.Remove(context.Background(), "http-remove", "Tom"); err == nil {
+			t.Fatalf("DELETE with secret %q should be refused", secret)
+		}
+	}
+	if _, ok := g.mainCache.get("Tom"); !ok {
+		t.Fatalf("refused DELETE should keep Tom")
+	}
+
+	peer := &httpGetter{baseURL: srv.URL + defaultBasePath, secret: "s3cret"}
+	if err := peer.Remove(context.Background(), "http-remove", "Tom"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatalf("DELETE should remove Tom from the local cache")
+	}
+	if err := peer.Remove(context.Background(), "no-such-group", "Tom"); err == nil {
+		t.Fatalf("removing from an unknown group should fail")
+	}
+}
+
+func TestClear(t *testing.T) {
+	a, b := &fakePeer{name: "a"}, &fakePeer{name: "b"}
+	g := NewGroup("clear", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	g.RegisterPeers(&fakePicker{all: []*fakePeer{a, b}})
+	g.Set("Tom", NewByteView([]byte("630")), 0)
+	g.Set("Jack", NewByteView([]byte("589")), 0)
+
+	if err := g.ClearAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatalf("Tom should be cleared")
+	}
+	if len(a.removed) != 1 || len(b.removed) != 1 || a.removed[0] != "clear/*" {
+		t.Fatalf("every peer should be cleared, got %v and %v", a.removed, b.removed)
+	}
+
+	g.Set("Sam", NewByteView([]byte("567")), 0)
+	srv, peer := newPeerServer()
+	defer srv.Close()
+	stranger := &httpGetter{baseURL: peer.baseURL}
+	if err := stranger.Clear(context.Background(), "clear"); err == nil {
+		t.Fatalf("DELETE on the group path without the peer secret should be refused")
+	}
+	if _, ok := g.mainCache.get("Sam"); !ok {
+		t.Fatalf("refused DELETE should keep Sam")
+	}
+	if err := peer.Clear(context.Background(), "clear"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.mainCache.get("Sam"); ok {
+		t.Fatalf("DELETE on the group path should clear it")
+	}
+}
+
+func TestSetPeerSecret(t *testing.T) {
+	pool := NewHTTPPool("a")
+	pool.Set("a", "b")
+	pool.SetPeerSecret("s3cret")
+	pool.Set("a", "b", "c")
+	for peer, getter := range pool.httpGetters {
+		if getter.secret != "s3cret" {
+			t.Fatalf("client of %s should carry the peer secret", peer)
+		}
+	}
+}
+
+// newPeerServer 启动一个设置了节点密钥的 HTTPPool，返回带着同一个密钥的客户端
+func newPeerServer() (*httptest.Server, *httpGetter) {
+	pool := NewHTTPPool("self")
+	pool.SetPeerSecret("s3cret")
+	srv := httptest.NewServer(pool)
+	return srv, &httpGetter{baseURL: srv.URL + defaultBasePath, secret: "s3cret"}
+}
+
+// notFoundPeer 对所有 key 都返回 ErrNotFound
+type notFoundPeer struct {
+	fakePeer
+}
+
+func (p *notFoundPeer) Get(ctx context.Context, group string, key string) ([]byte, error) {
+	p.fakePeer.Get(ctx, group, key)
+	return nil, ErrNotFound
+}
+
+type singlePicker struct {
+	peer PeerGetter
+}
+
+func (p singlePicker) PickPeer(key string) (PeerGetter, bool) { return p.peer, true }
+
+func (p singlePicker) GetAll() []PeerGetter { return []PeerGetter{p.peer} }
+
+func TestPeerNotFound(t *testing.T) {
+	loads := 0
+	peer := &notFoundPeer{fakePeer{name: "owner"}}
+	g := NewGroup("peer-not-found", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(key), nil
+	}), WithPeerBreaker(1, time.Minute))
+	g.RegisterPeers(singlePicker{peer: peer})
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.Get(context.Background(), fmt.Sprint("Tom", i)); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound from the owner, got %v", err)
+		}
+	}
+	if loads != 0 {
+		t.Fatalf("keys the owner doesn't have should not be loaded from the origin, loads = %d", loads)
+	}
+	if peer.gets != 2 {
+		t.Fatalf("not found should not open the breaker, peer gets = %d", peer.gets)
+	}
+}
+
+func TestHTTPNotFound(t *testing.T) {
+	NewGroup("http-not-found", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return nil, fmt.Errorf("%s: %w", key, ErrNotFound)
+	}))
+	srv, peer := newPeerServer()
+	defer srv.Close()
+
+	if _, err := peer.Get(context.Background(), "http-not-found", "Tom"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("404 should be returned as ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package geecache
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// WithRefreshAhead reloads a value in the background when it is hit less
+// than window before it expires, while the current value keeps being
+// served, so hot keys don't stall callers when they expire. It only has an
+// effect on values with a TTL.
+func WithRefreshAhead(window time.Duration) GroupOption {
+	return func(g *Group) {
+		g.refreshWindow = window
+	}
+}
+
+// WithStaleWhileRevalidate keeps values for window after they expire. A
+// hit on such a stale value returns it right away and reloads it in the
+// background, so callers aren't blocked by a slow or failing origin. Once
+// the window has passed the value is a miss again.
+func WithStaleWhileRevalidate(window time.Duration) GroupOption {
+	return func(g *Group) {
+		g.mainCache.stale = window
+		g.hotCache.stale = window
+	}
+}
+
+// maybeRefresh 在命中的值已经过期（stale）或快要过期时启动一次后台刷新，
+// 同一个 key 同时只有一个刷新。hot 表示命中的是热点缓存，刷新后更新热点副本。
+// 刷新在分组销毁时取消
+func (g *Group) maybeRefresh(key string, v ByteView, hot bool) {
+	if v.e.IsZero() {
+		return
+	}
+	if time.Until(v.e) > g.refreshWindow {
+		return
+	}
+	if _, loading := g.refreshing.LoadOrStore(key, struct{}{}); loading {
+		return
+	}
+	g.refreshes.Add(1)
+	go func() {
+		defer g.refreshes.Done()
+		defer g.refreshing.Delete(key)
+		value, err := g.load(g.detach(context.Background()), key)
+		if err != nil {
+			log.Println("[GeeCache] Failed to refresh", key, err)
+			return
+		}
+		// load 只按概率放进热点缓存，已经在里面的副本必须换成新值
+		if hot {
+			g.addHot(key, value)
+		}
+	}()
+}
@@ -0,0 +1,109 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshAhead(t *testing.T) {
+	var loads int64
+	g := NewGroup("refresh", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		n := atomic.AddInt64(&loads, 1)
+		return []byte(fmt.Sprintf("v%d", n)), nil
+	}), WithTTL(time.Minute), WithRefreshAhead(time.Minute))
+	ctx := context.Background()
+
+	g.Get(ctx, "Tom")
+	if v, _ := g.Get(ctx, "Tom"); v.String() != "v1" {
+		t.Fatalf("the current value should be served while refreshing, got %q", v)
+	}
+	g.refreshes.Wait()
+	if v, _ := g.mainCache.get("Tom"); v.String() != "v2" {
+		t.Fatalf("value nearing expiry should be refreshed in the background, got %q", v)
+	}
+}
+
+func TestRefreshAheadOutsideWindow(t *testing.T) {
+	var loads int64
+	g := NewGroup("refresh-outside", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		atomic.AddInt64(&loads, 1)
+		return []byte(key), nil
+	}), WithTTL(time.Minute), WithRefreshAhead(time.Second))
+
+	g.Get(context.Background(), "Tom")
+	g.Get(context.Background(), "Tom")
+	g.refreshes.Wait()
+	if n := atomic.LoadInt64(&loads); n != 1 {
+		t.Fatalf("values far from expiry should not be refreshed, loads = %d", n)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var loads int64
+	// 每次加载的值都已经过期，只能在 stale 窗口内使用
+	g := NewGroupContext("stale", 2<<10, GetterWithTTLFunc(func(ctx context.Context, key string) ([]byte, time.Time, error) {
+		n := atomic.AddInt64(&loads, 1)
+		if n == 2 {
+			return nil, time.Time{}, fmt.Errorf("origin down")
+		}
+		return []byte(fmt.Sprintf("v%d", n)), time.Now().Add(-time.Millisecond), nil
+	}), WithStaleWhileRevalidate(time.Minute))
+	ctx := context.Background()
+
+	g.Get(ctx, "Tom")
+	// 第一次刷新失败，仍然返回旧值
+	if v, err := g.Get(ctx, "Tom"); err != nil || v.String() != "v1" {
+		t.Fatalf("stale value should be served, got %q %v", v, err)
+	}
+	g.refreshes.Wait()
+	if v, err := g.Get(ctx, "Tom"); err != nil || v.String() != "v1" {
+		t.Fatalf("stale value should survive a failed refresh, got %q %v", v, err)
+	}
+	g.refreshes.Wait()
+	if v, _ := g.Get(ctx, "Tom"); v.String() != "v3" {
+		t.Fatalf("successful refresh should replace the stale value, got %q", v)
+	}
+}
+
+func TestRefreshHotCache(t *testing.T) {
+	owner := &ttlPeer{fakePeer: fakePeer{name: "owner"}, expire: time.Now().Add(time.Second)}
+	g := NewGroup("refresh-hot", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("local"), nil
+	}), WithHotCache(1<<10, 1, time.Minute), WithRefreshAhead(time.Minute))
+	g.RegisterPeers(&ttlPicker{peer: owner})
+	ctx := context.Background()
+
+	g.Get(ctx, "Tom")
+	// 之后的值不会再按概率放进热点缓存，刷新也必须更新热点副本
+	g.hotOneIn = 1 << 30
+	owner.name = "owner-v2"
+	owner.expire = time.Now().Add(time.Hour)
+	g.Get(ctx, "Tom")
+	g.refreshes.Wait()
+	if v, ok := g.hotCache.get("Tom"); !ok || v.String() != "owner-v2" {
+		t.Fatalf("refresh should update the hot copy, got %q", v)
+	}
+}
+
+func TestRefreshCanceledWithGroup(t *testing.T) {
+	var loads int64
+	blocked := make(chan struct{})
+	g := NewGroupContext("refresh-destroy", 2<<10, GetterCtxFunc(func(ctx context.Context, key string) ([]byte, error) {
+		if atomic.AddInt64(&loads, 1) == 1 {
+			return []byte(key), nil
+		}
+		// 刷新一直阻塞到分组销毁
+		close(blocked)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}), WithTTL(time.Minute), WithRefreshAhead(time.Minute))
+
+	g.Get(context.Background(), "Tom")
+	g.Get(context.Background(), "Tom")
+	<-blocked
+	DestroyGroup("refresh-destroy")
+	g.refreshes.Wait()
+}
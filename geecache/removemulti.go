@@ -0,0 +1,145 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	// removeRetries 是一批失效请求发给一个节点的最多尝试次数
+	removeRetries = 3
+	// removeRetryWait 是第一次重试前的等待时间，之后每次翻倍
+	removeRetryWait = 100 * time.Millisecond
+)
+
+// RemoveMulti removes many keys at once, e.g. after a bulk update at the
+// origin. Each peer gets the keys in batches of at most 1000 instead of one
+// request per key, and a failed batch is retried up to 3 times. Keys a peer
+// still fails to remove are logged and reported to Hooks.OnRemoveFailed, and
+// the first error is returned. Like Remove, a key is deleted locally only
+// after its owner has dropped it, and with WithBroadcastRemove the other
+// peers are invalidated too.
+func (g *Group) RemoveMulti(ctx context.Context, keys []string) error {
+	var done []string
+	owners := make(map[string]PeerGetter)
+	byPeer := make(map[PeerGetter][]string)
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if g.peers != nil {
+			if peer, ok := g.peers.PickPeer(key); ok {
+				owners[key] = peer
+				byPeer[peer] = append(byPeer[peer], key)
+				continue
+			}
+		}
+		done = append(done, key)
+	}
+
+	// 先删归属节点，删除失败的 key 本地也保留，和 Remove 一致
+	removed, err := g.removeFromPeers(ctx, byPeer)
+	done = append(done, removed...)
+	for _, key := range done {
+		g.removeLocally(key)
+	}
+	if !g.broadcastRemove || g.peers == nil {
+		return err
+	}
+
+	// 广播时跳过每个节点自己归属的 key，它们已经删过了
+	others := make(map[PeerGetter][]string)
+	for _, peer := range g.peers.GetAll() {
+		for _, key := range done {
+			if owners[key] != peer {
+				others[peer] = append(others[peer], key)
+			}
+		}
+	}
+	if _, berr := g.removeFromPeers(ctx, others); err == nil {
+		err = berr
+	}
+	return err
+}
+
+// removeFromPeers 并发地把 key 分批发给各个节点，返回删除成功的 key 和第一个错误
+func (g *Group) removeFromPeers(ctx context.Context, byPeer map[PeerGetter][]string) ([]string, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		removed  []string
+		firstErr error
+		sem      = make(chan struct{}, maxMultiLoads)
+	)
+	for peer, keys := range byPeer {
+		for _, batch := range batches(keys, maxMultiKeys) {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(peer PeerGetter, batch []string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				err := g.removeBatch(ctx, peer, batch)
+				if err != nil {
+					g.deadLetter(peer, batch, err)
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = fmt.Errorf("remove %d keys from %s: %w", len(batch), peerName(peer), err)
+					}
+					return
+				}
+				removed = append(removed, batch...)
+			}(peer, batch)
+		}
+	}
+	wg.Wait()
+	return removed, firstErr
+}
+
+// removeBatch 把一批 key 发给节点删除，失败时退避重试
+func (g *Group) removeBatch(ctx context.Context, peer PeerGetter, keys []string) error {
+	var err error
+	wait := removeRetryWait
+	for i := 0; i < removeRetries; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(wait):
+				wait *= 2
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = removeFromPeer(ctx, peer, g.name, keys); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// removeFromPeer 节点不支持批量失效时逐个发送
+func removeFromPeer(ctx context.Context, peer PeerGetter, group string, keys []string) error {
+	if mr, ok := peer.(PeerMultiRemover); ok {
+		return mr.RemoveMulti(ctx, group, keys)
+	}
+	for _, key := range keys {
+		if err := peer.Remove(ctx, group, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deadLetter 记录重试后仍然没能删除的 key，交给 OnRemoveFailed 稍后处理
+func (g *Group) deadLetter(peer PeerGetter, keys []string, err error) {
+	log.Printf("[GeeCache] Failed to remove %d keys from %s: %v", len(keys), peerName(peer), err)
+	for _, key := range keys {
+		g.emit(g.hooks.OnRemoveFailed, Event{Key: key, Peer: peerName(peer), Err: err})
+	}
+}
@@ -0,0 +1,116 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// batchPeer 记录收到的每一批失效请求
+type batchPeer struct {
+	fakePeer
+	batches [][]string
+	err     error
+}
+
+func (p *batchPeer) RemoveMulti(ctx context.Context, group string, keys []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.batches = append(p.batches, keys)
+	return p.err
+}
+
+// batchPicker 让 owner 负责所有 key，all 是广播的对象
+type batchPicker struct {
+	owner *batchPeer
+	all   []*batchPeer
+}
+
+func (p *batchPicker) PickPeer(key string) (PeerGetter, bool) {
+	return p.owner, true
+}
+
+func (p *batchPicker) GetAll() []PeerGetter {
+	peers := make([]PeerGetter, len(p.all))
+	for i, peer := range p.all {
+		peers[i] = peer
+	}
+	return peers
+}
+
+func TestRemoveMulti(t *testing.T) {
+	a, b := &batchPeer{fakePeer: fakePeer{name: "a"}}, &batchPeer{fakePeer: fakePeer{name: "b"}}
+	g := NewGroup("remove-multi", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithBroadcastRemove())
+	g.RegisterPeers(&batchPicker{owner: a, all: []*batchPeer{a, b}})
+	g.Set("Tom", NewByteView([]byte("630")), 0)
+	g.Set("Jack", NewByteView([]byte("589")), 0)
+
+	if err := g.RemoveMulti(context.Background(), []string{"Tom", "Jack", "Tom"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"Tom", "Jack"} {
+		if _, ok := g.mainCache.get(key); ok {
+			t.Fatalf("%s should be removed locally", key)
+		}
+	}
+	if len(a.batches) != 1 || len(a.batches[0]) != 2 {
+		t.Fatalf("the owner should get the keys in one request, got %v", a.batches)
+	}
+	if len(b.batches) != 1 {
+		t.Fatalf("other peers should get one broadcast request, got %v", b.batches)
+	}
+	got := append([]string(nil), b.batches[0]...)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, []string{"Jack", "Tom"}) {
+		t.Fatalf("broadcast keys = %v", got)
+	}
+}
+
+func TestRemoveMultiDeadLetter(t *testing.T) {
+	owner := &batchPeer{fakePeer: fakePeer{name: "owner"}, err: errors.New("peer down")}
+	var failed []string
+	g := NewGroup("remove-multi-dead", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithHooks(Hooks{OnRemoveFailed: func(e Event) {
+		failed = append(failed, e.Key)
+	}}))
+	g.RegisterPeers(&batchPicker{owner: owner})
+	g.Set("Tom", NewByteView([]byte("630")), 0)
+
+	if err := g.RemoveMulti(context.Background(), []string{"Tom"}); err == nil {
+		t.Fatal("RemoveMulti should report the failed peer")
+	}
+	if len(owner.batches) != removeRetries {
+		t.Fatalf("the batch should be tried %d times, got %d", removeRetries, len(owner.batches))
+	}
+	if !reflect.DeepEqual(failed, []string{"Tom"}) {
+		t.Fatalf("OnRemoveFailed should get the key, got %v", failed)
+	}
+	if _, ok := g.mainCache.get("Tom"); !ok {
+		t.Fatalf("a key its owner failed to remove should be kept locally, like Remove")
+	}
+}
+
+func TestHTTPRemoveMulti(t *testing.T) {
+	g := NewGroup("http-remove-multi", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	for _, key := range []string{"Tom", "Jack", "Sam"} {
+		g.Set(key, NewByteView([]byte(key)), 0)
+	}
+	srv, peer := newPeerServer()
+	defer srv.Close()
+
+	if err := peer.RemoveMulti(context.Background(), "http-remove-multi", []string{"Tom", "Jack"}); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"Tom": false, "Jack": false, "Sam": true} {
+		if _, ok := g.mainCache.get(key); ok != want {
+			t.Fatalf("%s cached = %v, want %v", key, ok, want)
+		}
+	}
+}
@@ -42,3 +42,20 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 
 	return c.val, c.err
 }
+
+// Result 是 DoChan 返回的结果
+type Result struct {
+	Val interface{}
+	Err error
+}
+
+// DoChan 和 Do 一样，但不会阻塞，结果从返回的 channel 里读取。
+// 调用方不再等待时 fn 照常执行完，结果仍然交给其他等待的调用方。
+func (g *Group) DoChan(key string, fn func() (interface{}, error)) <-chan Result {
+	ch := make(chan Result, 1)
+	go func() {
+		val, err := g.Do(key, fn)
+		ch <- Result{Val: val, Err: err}
+	}()
+	return ch
+}
@@ -0,0 +1,273 @@
+package geecache
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+// slabStore 参考 BigCache：每个分片把条目顺序写进一块大的 []byte，
+// 索引只是 map[uint64]int（key 的哈希 -> 偏移量），不含指针，
+// 缓存几十 GB 数据时 GC 也不需要扫描这些条目。
+// 淘汰策略是 FIFO：空间不够时从最旧的条目开始丢弃。
+type slabStore struct {
+	shards []*slabShard
+}
+
+type slabShard struct {
+	index     map[uint64]int
+	buf       []byte
+	head      int // 最旧条目的偏移量，之前的字节都已失效
+	live      int // 索引仍指向的条目占用的字节数，head 之后的其余字节是删除或覆盖留下的死字节
+	evictions int64
+	onEvicted func(key string, value ByteView)
+	maxBytes  int
+}
+
+// 条目格式：[key 长度 4 字节][value 长度 4 字节][过期时间 8 字节][版本 8 字节][key][value]
+// 过期时间是 UnixNano，0 表示永不过期
+const slabHeaderSize = 24
+
+// NewSlabStore returns a StoreFunc creating a sharded, GC-friendly Store that
+// keeps entries in large byte slabs instead of individual heap objects.
+func NewSlabStore(shards int) StoreFunc {
+	if shards <= 0 {
+		shards = 1
+	}
+	return func(maxBytes int64) Store {
+		// 整除得到 0 就变成不限制了，至少给每个分片 1 字节
+		shardBytes := maxBytes / int64(shards)
+		if maxBytes > 0 && shardBytes == 0 {
+			shardBytes = 1
+		}
+		s := &slabStore{shards: make([]*slabShard, shards)}
+		for i := range s.shards {
+			s.shards[i] = &slabShard{
+				index:    make(map[uint64]int),
+				maxBytes: int(shardBytes),
+			}
+		}
+		return s
+	}
+}
+
+// fnv64a 内联实现，避免 hash.Hash 带来的内存分配
+func fnv64a(key string) uint64 {
+	var h uint64 = 14695981039346656037
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+func (s *slabStore) shard(h uint64) *slabShard {
+	return s.shards[h%uint64(len(s.shards))]
+}
+
+func (s *slabStore) Get(key string) (value ByteView, ok bool) {
+	h := fnv64a(key)
+	sh := s.shard(h)
+	off, ok := sh.index[h]
+	if !ok {
+		return
+	}
+	if k, _ := sh.entry(off); k != key {
+		return ByteView{}, false
+	}
+	return sh.view(off), true
+}
+
+// Peek 和 Get 一样，slab 按写入顺序淘汰，查找本来就不改变任何状态
+func (s *slabStore) Peek(key string) (value ByteView, ok bool) {
+	return s.Get(key)
+}
+
+func (s *slabStore) Add(key string, value ByteView) {
+	h := fnv64a(key)
+	s.shard(h).add(h, key, value)
+}
+
+// Remove 只删除索引，字节留在 buf 里等淘汰或压缩时回收，见 slabShard.add
+func (s *slabStore) Remove(key string) {
+	h := fnv64a(key)
+	sh := s.shard(h)
+	if off, ok := sh.index[h]; ok {
+		if k, _ := sh.entry(off); k == key {
+			sh.unlink(h, off)
+		}
+	}
+}
+
+// RemoveOldest 淘汰有效字节最多的分片中最旧的有效条目
+func (s *slabStore) RemoveOldest() {
+	sh := s.shards[0]
+	for _, other := range s.shards[1:] {
+		if other.live > sh.live {
+			sh = other
+		}
+	}
+	for len(sh.index) > 0 {
+		if sh.removeOldest() {
+			sh.evictions++
+			return
+		}
+	}
+}
+
+func (s *slabStore) Range(fn func(key string, value ByteView) bool) {
+	for _, sh := range s.shards {
+		for _, off := range sh.index {
+			k, _ := sh.entry(off)
+			if !fn(k, sh.view(off)) {
+				return
+			}
+		}
+	}
+}
+
+func (s *slabStore) Len() int {
+	n := 0
+	for _, sh := range s.shards {
+		n += len(sh.index)
+	}
+	return n
+}
+
+func (s *slabStore) Bytes() int64 {
+	var n int64
+	for _, sh := range s.shards {
+		n += int64(sh.live)
+	}
+	return n
+}
+
+func (s *slabStore) Evictions() int64 {
+	var n int64
+	for _, sh := range s.shards {
+		n += sh.evictions
+	}
+	return n
+}
+
+func (s *slabStore) SetOnEvicted(fn func(key string, value ByteView)) {
+	for _, sh := range s.shards {
+		sh.onEvicted = fn
+	}
+}
+
+func (sh *slabShard) entry(off int) (key string, value []byte) {
+	kl := int(binary.LittleEndian.Uint32(sh.buf[off:]))
+	vl := int(binary.LittleEndian.Uint32(sh.buf[off+4:]))
+	start := off + slabHeaderSize
+	return string(sh.buf[start : start+kl]), sh.buf[start+kl : start+kl+vl]
+}
+
+// view 取出 off 处条目的值，buf 会被复用和压缩，必须拷贝出来
+func (sh *slabShard) view(off int) ByteView {
+	_, v := sh.entry(off)
+	view := ByteView{b: cloneBytes(v), version: int64(binary.LittleEndian.Uint64(sh.buf[off+16:]))}
+	if ns := int64(binary.LittleEndian.Uint64(sh.buf[off+8:])); ns != 0 {
+		view.e = time.Unix(0, ns)
+	}
+	return view
+}
+
+func (sh *slabShard) unlink(h uint64, off int) {
+	delete(sh.index, h)
+	sh.live -= sh.entrySize(off)
+}
+
+func (sh *slabShard) entrySize(off int) int {
+	kl := int(binary.LittleEndian.Uint32(sh.buf[off:]))
+	vl := int(binary.LittleEndian.Uint32(sh.buf[off+4:]))
+	return slabHeaderSize + kl + vl
+}
+
+func (sh *slabShard) add(h uint64, key string, view ByteView) {
+	value := view.b
+	size := slabHeaderSize + len(key) + len(value)
+	if sh.maxBytes > 0 && size > sh.maxBytes {
+		return
+	}
+	// 旧值留在 buf 里，等淘汰或压缩时回收
+	if off, ok := sh.index[h]; ok {
+		sh.unlink(h, off)
+	}
+	// 死字节比有效字节多时整理一次，不让它们占着预算挤掉有效条目
+	if dead := len(sh.buf) - sh.head - sh.live; dead > 0 && dead >= sh.live {
+		sh.rewrite()
+	}
+	for sh.maxBytes > 0 && len(sh.buf)-sh.head+size > sh.maxBytes {
+		if sh.removeOldest() {
+			sh.evictions++
+		}
+	}
+	if sh.head > 0 && sh.head >= len(sh.buf)/2 {
+		sh.compact()
+	}
+
+	off := len(sh.buf)
+	var header [slabHeaderSize]byte
+	binary.LittleEndian.PutUint32(header[:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(value)))
+	if !view.e.IsZero() {
+		binary.LittleEndian.PutUint64(header[8:], uint64(view.e.UnixNano()))
+	}
+	binary.LittleEndian.PutUint64(header[16:], uint64(view.version))
+	sh.buf = append(sh.buf, header[:]...)
+	sh.buf = append(sh.buf, key...)
+	sh.buf = append(sh.buf, value...)
+	sh.index[h] = off
+	sh.live += size
+}
+
+// removeOldest 丢掉最旧的条目，返回它是否还是有效条目
+func (sh *slabShard) removeOldest() (live bool) {
+	if sh.head >= len(sh.buf) {
+		return false
+	}
+	k, _ := sh.entry(sh.head)
+	h := fnv64a(k)
+	// 只有索引仍指向这个位置时才是有效条目
+	if off, ok := sh.index[h]; ok && off == sh.head {
+		if sh.onEvicted != nil {
+			sh.onEvicted(k, sh.view(off))
+		}
+		sh.unlink(h, off)
+		live = true
+	}
+	sh.head += sh.entrySize(sh.head)
+	if sh.head == len(sh.buf) {
+		sh.buf = sh.buf[:0]
+		sh.head = 0
+	}
+	return live
+}
+
+// rewrite 按原来的顺序只把有效条目重新写到 buf 开头，丢掉所有死字节
+func (sh *slabShard) rewrite() {
+	n := 0
+	for off := sh.head; off < len(sh.buf); {
+		size := sh.entrySize(off)
+		k, _ := sh.entry(off)
+		h := fnv64a(k)
+		if cur, ok := sh.index[h]; ok && cur == off {
+			copy(sh.buf[n:], sh.buf[off:off+size])
+			sh.index[h] = n
+			n += size
+		}
+		off += size
+	}
+	sh.buf = sh.buf[:n]
+	sh.head = 0
+}
+
+// compact 把有效区域挪到 buf 开头，并修正索引中的偏移量
+func (sh *slabShard) compact() {
+	n := copy(sh.buf, sh.buf[sh.head:])
+	for h, off := range sh.index {
+		sh.index[h] = off - sh.head
+	}
+	sh.buf = sh.buf[:n]
+	sh.head = 0
+}
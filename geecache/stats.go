@@ -0,0 +1,64 @@
+package geecache
+
+import "sync/atomic"
+
+// Stats is a snapshot of a group's counters, see Group.Stats.
+type Stats struct {
+	Gets       int64 `json:"gets"` // Get 被调用的次数
+	Hits       int64 `json:"hits"` // mainCache 或 hotCache 命中的次数
+	Misses     int64 `json:"misses"`
+	LocalLoads int64 `json:"local_loads"` // 调用本地 Getter 成功的次数
+	LoadErrors int64 `json:"load_errors"` // 调用本地 Getter 失败的次数
+	PeerLoads  int64 `json:"peer_loads"`  // 从远程节点获取成功的次数
+	PeerErrors int64 `json:"peer_errors"`
+	ShedLoads  int64 `json:"shed_loads"` // 超过 WithLoadLimit 被拒绝的加载次数
+	Oversized  int64 `json:"oversized"`  // 超过 WithMaxValueSize 没有缓存的值的个数
+	Evictions  int64 `json:"evictions"`  // 因为空间不足被淘汰的条目数
+	Bytes      int64 `json:"bytes"`      // mainCache 和 hotCache 当前占用的字节数
+	Items      int64 `json:"items"`
+}
+
+// groupStats 的字段都用 sync/atomic 更新
+type groupStats struct {
+	gets       int64
+	hits       int64
+	misses     int64
+	localLoads int64
+	loadErrors int64
+	peerLoads  int64
+	peerErrors int64
+	oversized  int64
+	shedLoads  int64
+}
+
+// Stats returns the group's counters. Counters only grow; Bytes and Items
+// describe the cache right now.
+func (g *Group) Stats() Stats {
+	st := Stats{
+		Gets:       atomic.LoadInt64(&g.stats.gets),
+		Hits:       atomic.LoadInt64(&g.stats.hits),
+		Misses:     atomic.LoadInt64(&g.stats.misses),
+		LocalLoads: atomic.LoadInt64(&g.stats.localLoads),
+		LoadErrors: atomic.LoadInt64(&g.stats.loadErrors),
+		PeerLoads:  atomic.LoadInt64(&g.stats.peerLoads),
+		PeerErrors: atomic.LoadInt64(&g.stats.peerErrors),
+		Oversized:  atomic.LoadInt64(&g.stats.oversized),
+		ShedLoads:  atomic.LoadInt64(&g.stats.shedLoads),
+	}
+	for _, c := range []*cache{&g.mainCache, &g.hotCache} {
+		bytes, items, evictions := c.stats()
+		st.Bytes += bytes
+		st.Items += items
+		st.Evictions += evictions
+	}
+	return st
+}
+
+// count 在 err 为空时给 ok 加一，否则给 failed 加一
+func count(err error, ok *int64, failed *int64) {
+	if err != nil {
+		atomic.AddInt64(failed, 1)
+		return
+	}
+	atomic.AddInt64(ok, 1)
+}
@@ -0,0 +1,58 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	g := NewGroup("stats", 20, GetterFunc(func(key string) ([]byte, error) {
+		if key == "bad" {
+			return nil, fmt.Errorf("%s not exist", key)
+		}
+		return []byte("12345"), nil
+	}))
+	ctx := context.Background()
+	g.Get(ctx, "k1")
+	g.Get(ctx, "k1")
+	g.Get(ctx, "bad")
+	g.Get(ctx, "k2")
+	g.Get(ctx, "k3") // 容量只够两个条目，k1 被淘汰
+
+	st := g.Stats()
+	want := Stats{Gets: 5, Hits: 1, Misses: 4, LocalLoads: 3, LoadErrors: 1, Evictions: 1, Bytes: 14, Items: 2}
+	if st != want {
+		t.Fatalf("stats = %+v, want %+v", st, want)
+	}
+
+	g.Remove(ctx, "k2")
+	g.Clear()
+	if st := g.Stats(); st.Evictions != 1 || st.Bytes != 0 || st.Items != 0 {
+		t.Fatalf("Remove and Clear should not count as evictions, got %+v", st)
+	}
+}
+
+func TestMaxValueSize(t *testing.T) {
+	g := NewGroup("max-value", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithMaxValueSize(4))
+	ctx := context.Background()
+
+	if v, err := g.Get(ctx, "huge-value"); err != nil || v.String() != "huge-value" {
+		t.Fatalf("oversized value should still be returned, got %q %v", v, err)
+	}
+	g.Get(ctx, "tiny")
+	if _, ok := g.mainCache.get("huge-value"); ok {
+		t.Fatalf("oversized value should bypass the cache")
+	}
+	if _, ok := g.mainCache.get("tiny"); !ok {
+		t.Fatalf("small value should be cached")
+	}
+	if err := g.Set("blob", NewByteView([]byte("12345")), 0); err == nil {
+		t.Fatalf("Set should reject an oversized value")
+	}
+	if st := g.Stats(); st.Oversized != 2 {
+		t.Fatalf("Oversized = %d, want 2", st.Oversized)
+	}
+}
@@ -0,0 +1,173 @@
+package geecache
+
+import (
+	"geecache/arc"
+	"geecache/lfu"
+	"geecache/lru"
+)
+
+// Store 是 mainCache 背后真正保存字节的存储层，默认是内存中的 LRU，
+// 也可以用 WithStore 按分组换成 NewLFUStore、NewARCStore、NewTinyLFUStore 等其他实现，
+// 或者用 WithPolicy 接入自定义的淘汰策略。
+// 实现不需要并发安全，cache 会在外层加锁。
+type Store interface {
+	Get(key string) (value ByteView, ok bool)
+	Add(key string, value ByteView)
+	Remove(key string)
+	// RemoveOldest 按淘汰策略淘汰一个条目，和空间不足时一样计入 Evictions
+	RemoveOldest()
+	// Range 遍历所有条目直到 fn 返回 false，遍历过程中不能修改 Store
+	Range(fn func(key string, value ByteView) bool)
+	Len() int
+	Bytes() int64
+	// Evictions 返回因为空间不足被淘汰的条目数，不包括 Remove 删除的
+	Evictions() int64
+	// SetOnEvicted 设置条目因为空间不足被淘汰时的回调，Remove 不触发
+	SetOnEvicted(fn func(key string, value ByteView))
+}
+
+// Peeker can be implemented by a Store that can look a key up without
+// updating its eviction order. Peek may run concurrently with other Peeks.
+// The cache then serves hits under a read lock and replays them through
+// Get in batches, so reads of a hot cache don't queue on one lock.
+type Peeker interface {
+	Peek(key string) (value ByteView, ok bool)
+}
+
+// StoreFunc creates a Store that holds at most maxBytes bytes.
+type StoreFunc func(maxBytes int64) Store
+
+// Policy is an eviction policy with byte accounting like lru.Cache,
+// lfu.Cache and arc.Cache. Implementations need not be safe for concurrent
+// use. Wrap one with PolicyStore or WithPolicy to use a custom policy
+// without writing a whole Store.
+type Policy interface {
+	Get(key string) (value lru.Value, ok bool)
+	Add(key string, value lru.Value)
+	Remove(key string)
+	// RemoveOldest 淘汰策略认为最不值得保留的条目
+	RemoveOldest()
+	// Range 遍历所有条目直到 fn 返回 false，不算作访问
+	Range(fn func(key string, value lru.Value) bool)
+	Len() int
+	Bytes() int64
+}
+
+// PolicyFunc creates a Policy holding at most maxBytes bytes, 0 meaning no
+// limit, that calls onEvicted for every entry it evicts or removes.
+type PolicyFunc func(maxBytes int64, onEvicted func(key string, value lru.Value)) Policy
+
+// PolicyStore returns a StoreFunc creating Stores backed by the policies
+// fn creates. Values stored in them are always ByteViews.
+func PolicyStore(fn PolicyFunc) StoreFunc {
+	return func(maxBytes int64) Store {
+		s := &policyStore{}
+		s.p = fn(maxBytes, func(key string, value lru.Value) {
+			if s.removing {
+				return
+			}
+			s.evictions++
+			if s.onEvicted != nil {
+				s.onEvicted(key, value.(ByteView))
+			}
+		})
+		if _, ok := s.p.(policyPeeker); ok {
+			return peekingPolicyStore{s}
+		}
+		return s
+	}
+}
+
+// policyPeeker 是支持 Peek 的 Policy，包装成的 Store 也就实现了 Peeker
+type policyPeeker interface {
+	Peek(key string) (value lru.Value, ok bool)
+}
+
+type peekingPolicyStore struct {
+	*policyStore
+}
+
+func (s peekingPolicyStore) Peek(key string) (value ByteView, ok bool) {
+	if v, ok := s.p.(policyPeeker).Peek(key); ok {
+		return v.(ByteView), true
+	}
+	return
+}
+
+// policyStore 把 Policy 包装成 Store
+type policyStore struct {
+	p         Policy
+	evictions int64
+	onEvicted func(key string, value ByteView)
+	// removing 为 true 时是 Remove 触发的 OnEvicted，不算淘汰
+	removing bool
+}
+
+// NewLRUStore returns the default Store backed by lru.Cache.
+func NewLRUStore(maxBytes int64) Store {
+	return PolicyStore(func(maxBytes int64, onEvicted func(string, lru.Value)) Policy {
+		return lru.New(maxBytes, onEvicted)
+	})(maxBytes)
+}
+
+// NewLFUStore returns a Store backed by lfu.Cache, which evicts the least
+// frequently used entry. It suits frequency-skewed workloads where a scan
+// over cold keys would push hot ones out of an LRU.
+func NewLFUStore(maxBytes int64) Store {
+	return PolicyStore(func(maxBytes int64, onEvicted func(string, lru.Value)) Policy {
+		return lfu.New(maxBytes, onEvicted)
+	})(maxBytes)
+}
+
+// NewARCStore returns a Store backed by arc.Cache, which adapts between
+// recency and frequency to the workload and keeps frequently used entries
+// through scans.
+func NewARCStore(maxBytes int64) Store {
+	return PolicyStore(func(maxBytes int64, onEvicted func(string, lru.Value)) Policy {
+		return arc.New(maxBytes, onEvicted)
+	})(maxBytes)
+}
+
+func (s *policyStore) Get(key string) (value ByteView, ok bool) {
+	if v, ok := s.p.Get(key); ok {
+		return v.(ByteView), true
+	}
+	return
+}
+
+func (s *policyStore) Add(key string, value ByteView) {
+	s.p.Add(key, value)
+}
+
+func (s *policyStore) Remove(key string) {
+	// Remove 也会触发 OnEvicted，主动删除不算淘汰
+	s.removing = true
+	s.p.Remove(key)
+	s.removing = false
+}
+
+func (s *policyStore) RemoveOldest() {
+	s.p.RemoveOldest()
+}
+
+func (s *policyStore) Range(fn func(key string, value ByteView) bool) {
+	s.p.Range(func(key string, value lru.Value) bool {
+		return fn(key, value.(ByteView))
+	})
+}
+
+func (s *policyStore) Len() int {
+	return s.p.Len()
+}
+
+func (s *policyStore) Bytes() int64 {
+	return s.p.Bytes()
+}
+
+func (s *policyStore) Evictions() int64 {
+	return s.evictions
+}
+
+func (s *policyStore) SetOnEvicted(fn func(key string, value ByteView)) {
+	s.onEvicted = fn
+}
@@ -0,0 +1,196 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"geecache/lru"
+	"testing"
+)
+
+func TestSlabStore(t *testing.T) {
+	s := NewSlabStore(1)(int64(2 * (slabHeaderSize + 2 + 4)))
+	s.Add("k1", ByteView{b: []byte("1111")})
+	s.Add("k2", ByteView{b: []byte("2222")})
+	if v, ok := s.Get("k1"); !ok || v.String() != "1111" {
+		t.Fatalf("slab store hit k1=1111 failed")
+	}
+
+	s.Add("k3", ByteView{b: []byte("3333")})
+	if _, ok := s.Get("k1"); ok || s.Len() != 2 || s.Evictions() != 1 {
+		t.Fatalf("slab store should evict the oldest entry k1")
+	}
+
+	s.Add("k2", ByteView{b: []byte("abcd")})
+	if v, ok := s.Get("k2"); !ok || v.String() != "abcd" {
+		t.Fatalf("slab store update k2 failed")
+	}
+}
+
+func TestSlabStoreTinyBudget(t *testing.T) {
+	// 8 字节分给 16 个分片时每个分片不能变成不限制
+	s := NewSlabStore(16)(8)
+	for i := 0; i < 100; i++ {
+		s.Add(fmt.Sprint(i), NewByteView([]byte("v")))
+	}
+	if s.Len() != 0 {
+		t.Fatalf("shards should stay bounded, kept %d entries", s.Len())
+	}
+}
+
+func TestSlabStoreReclaimsRemoved(t *testing.T) {
+	// 每个条目 24+2+1 字节，预算正好放下 10 个
+	s := NewSlabStore(1)(270)
+	for i := 0; i < 10; i++ {
+		s.Add(fmt.Sprint("k", i), NewByteView([]byte("v")))
+	}
+	for i := 1; i < 10; i++ {
+		s.Remove(fmt.Sprint("k", i))
+	}
+	for i := 1; i < 10; i++ {
+		s.Add(fmt.Sprint("n", i), NewByteView([]byte("v")))
+	}
+	if _, ok := s.Get("k0"); !ok || s.Len() != 10 {
+		t.Fatalf("removed bytes should be reclaimed before evicting, len = %d", s.Len())
+	}
+	if v, ok := s.Get("n9"); !ok || v.String() != "v" {
+		t.Fatalf("entries should survive the rewrite, got %q", v)
+	}
+}
+
+func TestGroupWithStore(t *testing.T) {
+	g := NewGroup("slab", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithStore(NewSlabStore(4)))
+	if v, err := g.Get(context.Background(), "Tom"); err != nil || v.String() != "Tom" {
+		t.Fatalf("failed to get value of Tom")
+	}
+	if _, ok := g.mainCache.get("Tom"); !ok {
+		t.Fatalf("value of Tom should be cached in the slab store")
+	}
+}
+
+func TestLFUStore(t *testing.T) {
+	s := NewLFUStore(int64(2 * (2 + 4)))
+	s.Add("k1", ByteView{b: []byte("1111")})
+	s.Get("k1")
+	// 一次扫描只访问一次的 key，不应该挤掉被多次访问的 k1
+	s.Add("k2", ByteView{b: []byte("2222")})
+	s.Add("k3", ByteView{b: []byte("3333")})
+	if _, ok := s.Get("k1"); !ok || s.Len() != 2 || s.Evictions() != 1 {
+		t.Fatalf("lfu store should keep the frequently used k1")
+	}
+	if _, ok := s.Get("k2"); ok {
+		t.Fatalf("lfu store should evict k2")
+	}
+}
+
+func TestARCStore(t *testing.T) {
+	g := NewGroup("arc", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithStore(NewARCStore))
+	if v, err := g.Get(context.Background(), "Tom"); err != nil || v.String() != "Tom" {
+		t.Fatalf("failed to get value of Tom")
+	}
+	if _, ok := g.mainCache.get("Tom"); !ok {
+		t.Fatalf("value of Tom should be cached in the arc store")
+	}
+}
+
+func TestTinyLFUStore(t *testing.T) {
+	// 每个条目 2+196 字节，比窗口大，直接交给准入判断；主缓存能放三个
+	s := NewTinyLFUStore(100)(600)
+	value := ByteView{b: make([]byte, 196)}
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("h%d", i)
+		s.Add(key, value)
+		s.Get(key)
+		s.Get(key)
+	}
+	// 只访问一次的 key 频率比主缓存中最旧的条目低，进不了主缓存
+	for i := 0; i < 10; i++ {
+		s.Add(fmt.Sprintf("s%d", i), value)
+	}
+	for i := 0; i < 3; i++ {
+		if _, ok := s.Get(fmt.Sprintf("h%d", i)); !ok {
+			t.Fatalf("hot key h%d should not be evicted by the scan", i)
+		}
+	}
+	if s.Len() != 3 || s.Evictions() != 10 {
+		t.Fatalf("Len = %d, Evictions = %d", s.Len(), s.Evictions())
+	}
+
+	// 访问频率超过最旧条目的新 key 可以进入主缓存
+	for i := 0; i < 5; i++ {
+		s.Get("new")
+	}
+	s.Add("new", value)
+	if _, ok := s.Get("new"); !ok {
+		t.Fatalf("a frequently requested key should be admitted")
+	}
+}
+
+// countingPolicy 记录 Add 的次数，用来确认分组使用的是自定义策略
+type countingPolicy struct {
+	*lru.Cache
+	adds int
+}
+
+func (p *countingPolicy) Add(key string, value lru.Value) {
+	p.adds++
+	p.Cache.Add(key, value)
+}
+
+func TestWithPolicy(t *testing.T) {
+	var policy *countingPolicy
+	g := NewGroup("policy", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithPolicy(func(maxBytes int64, onEvicted func(string, lru.Value)) Policy {
+		policy = &countingPolicy{Cache: lru.New(maxBytes, onEvicted)}
+		return policy
+	}))
+	if v, err := g.Get(context.Background(), "Tom"); err != nil || v.String() != "Tom" {
+		t.Fatalf("failed to get value of Tom")
+	}
+	if policy == nil || policy.adds != 1 {
+		t.Fatalf("value of Tom should be added to the custom policy")
+	}
+}
+
+func TestStoreRemoveOldest(t *testing.T) {
+	stores := map[string]StoreFunc{
+		"lru":     NewLRUStore,
+		"lfu":     NewLFUStore,
+		"arc":     NewARCStore,
+		"tinylfu": NewTinyLFUStore(16),
+		"slab":    NewSlabStore(2),
+	}
+	for name, fn := range stores {
+		s := fn(2 << 10)
+		s.Add("k1", ByteView{b: []byte("1111")})
+		s.Add("k2", ByteView{b: []byte("2222")})
+		s.RemoveOldest()
+		if s.Len() != 1 || s.Evictions() != 1 {
+			t.Fatalf("%s: Len = %d, Evictions = %d after RemoveOldest", name, s.Len(), s.Evictions())
+		}
+	}
+}
+
+func TestStorePeeker(t *testing.T) {
+	stores := map[string]StoreFunc{
+		"lru":     NewLRUStore,
+		"lfu":     NewLFUStore,
+		"arc":     NewARCStore,
+		"tinylfu": NewTinyLFUStore(16),
+		"slab":    NewSlabStore(2),
+	}
+	for name, fn := range stores {
+		s, ok := fn(2 << 10).(Peeker)
+		if !ok {
+			t.Fatalf("%s: store should implement Peeker", name)
+		}
+		s.(Store).Add("k1", ByteView{b: []byte("1111")})
+		if v, ok := s.Peek("k1"); !ok || v.String() != "1111" {
+			t.Fatalf("%s: Peek k1=1111 failed", name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package tinylfu implements the frequency sketch used by TinyLFU admission:
+// a count-min sketch whose counters are halved periodically, so the
+// estimates follow recent popularity instead of all-time counts.
+package tinylfu
+
+// maxCount 是每个计数器的上限，TinyLFU 只需要比较频率的高低
+const maxCount = 15
+
+const depth = 4
+
+// Sketch 是带衰减的 count-min sketch，并发访问不安全
+type Sketch struct {
+	rows  [depth][]uint8
+	mask  uint64
+	added int
+	// resetAt 次 Increment 之后所有计数器减半
+	resetAt int
+}
+
+// New creates a Sketch for about n distinct keys. Counters are halved
+// after every 10*n increments.
+func New(n int) *Sketch {
+	if n <= 0 {
+		n = 1
+	}
+	width := 1
+	for width < n {
+		width <<= 1
+	}
+	s := &Sketch{mask: uint64(width - 1), resetAt: 10 * n}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// Increment records one access to key.
+func (s *Sketch) Increment(key string) {
+	h1, h2 := hash(key)
+	for i := range s.rows {
+		idx := (h1 + uint64(i)*h2) & s.mask
+		if s.rows[i][idx] < maxCount {
+			s.rows[i][idx]++
+		}
+	}
+	s.added++
+	if s.added >= s.resetAt {
+		s.reset()
+	}
+}
+
+// Estimate returns how often key was accessed recently. It may overcount
+// because of collisions, never undercount.
+func (s *Sketch) Estimate(key string) int {
+	h1, h2 := hash(key)
+	count := uint8(maxCount)
+	for i := range s.rows {
+		if c := s.rows[i][(h1+uint64(i)*h2)&s.mask]; c < count {
+			count = c
+		}
+	}
+	return int(count)
+}
+
+// Admit reports whether candidate is accessed more often than victim and
+// should replace it in the cache.
+func (s *Sketch) Admit(candidate, victim string) bool {
+	return s.Estimate(candidate) > s.Estimate(victim)
+}
+
+// reset 把所有计数器减半，让旧的访问逐渐失去影响
+func (s *Sketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.added /= 2
+}
+
+// hash 用 fnv-1a 的 64 位结果拆出两个哈希，第 i 行用 h1 + i*h2
+func hash(key string) (uint64, uint64) {
+	var h uint64 = 14695981039346656037
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return h & 0xffffffff, h>>32 | 1
+}
@@ -0,0 +1,34 @@
+package tinylfu
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEstimate(t *testing.T) {
+	s := New(100)
+	for i := 0; i < 5; i++ {
+		s.Increment("hot")
+	}
+	s.Increment("cold")
+	if s.Estimate("hot") < 5 || s.Estimate("cold") < 1 {
+		t.Fatalf("estimates should never undercount: hot=%d cold=%d", s.Estimate("hot"), s.Estimate("cold"))
+	}
+	if !s.Admit("hot", "cold") || s.Admit("cold", "hot") {
+		t.Fatalf("hot should be admitted over cold, not the other way")
+	}
+}
+
+func TestReset(t *testing.T) {
+	s := New(10)
+	for i := 0; i < 8; i++ {
+		s.Increment("old")
+	}
+	// 10*n 次访问之后计数器减半，过去的热点逐渐冷却
+	for i := 0; i < 100; i++ {
+		s.Increment(fmt.Sprintf("k%d", i))
+	}
+	if got := s.Estimate("old"); got >= 8 {
+		t.Fatalf("old count should decay, got %d", got)
+	}
+}
@@ -0,0 +1,154 @@
+package geecache
+
+import (
+	"geecache/lru"
+	"geecache/tinylfu"
+)
+
+// tinyLFUWindowRatio 是窗口 LRU 占总容量的比例的倒数
+const tinyLFUWindowRatio = 100
+
+// tinyLFUStore 是 W-TinyLFU：新条目先进入一个很小的窗口 LRU，
+// 被挤出窗口时只有访问频率高于主缓存里最旧的条目才能进入主缓存，
+// 否则直接丢弃，所以只访问一次的 key 挤不掉真正的热点
+type tinyLFUStore struct {
+	window    *lru.Cache
+	main      *lru.Cache
+	mainBytes int64
+	sketch    *tinylfu.Sketch
+	evictions int64
+	onEvicted func(key string, value ByteView)
+	// removing 为 true 时是 Remove 触发的 OnEvicted，不算淘汰
+	removing bool
+}
+
+// NewTinyLFUStore returns a StoreFunc creating a Store with W-TinyLFU
+// admission: an entry pushed out of a small LRU window only enters the main
+// LRU if it was accessed more often than the entry it would evict, so
+// one-hit-wonder keys, e.g. from crawlers, don't evict hot entries.
+// entries is the expected number of cached keys and sizes the frequency
+// sketch.
+func NewTinyLFUStore(entries int) StoreFunc {
+	return func(maxBytes int64) Store {
+		s := &tinyLFUStore{sketch: tinylfu.New(entries)}
+		windowBytes := maxBytes / tinyLFUWindowRatio
+		if windowBytes == 0 {
+			windowBytes = 1
+		}
+		if maxBytes > 0 {
+			s.mainBytes = maxBytes - windowBytes
+		}
+		s.window = lru.New(windowBytes, func(key string, value lru.Value) {
+			if !s.removing {
+				s.admit(key, value.(ByteView))
+			}
+		})
+		s.main = lru.New(s.mainBytes, func(key string, value lru.Value) {
+			if !s.removing {
+				s.evicted(key, value.(ByteView))
+			}
+		})
+		return s
+	}
+}
+
+// admit 决定被挤出窗口的条目能否进入主缓存
+func (s *tinyLFUStore) admit(key string, value ByteView) {
+	size := int64(len(key) + value.Len())
+	if s.mainBytes > 0 && s.main.Bytes()+size > s.mainBytes {
+		if victim, _, ok := s.main.GetOldest(); ok && !s.sketch.Admit(key, victim) {
+			s.evicted(key, value)
+			return
+		}
+	}
+	s.main.Add(key, value)
+}
+
+func (s *tinyLFUStore) evicted(key string, value ByteView) {
+	s.evictions++
+	if s.onEvicted != nil {
+		s.onEvicted(key, value)
+	}
+}
+
+func (s *tinyLFUStore) Get(key string) (value ByteView, ok bool) {
+	s.sketch.Increment(key)
+	if v, ok := s.window.Get(key); ok {
+		return v.(ByteView), true
+	}
+	if v, ok := s.main.Get(key); ok {
+		return v.(ByteView), true
+	}
+	return
+}
+
+// Peek 不计入 sketch，访问记录由 cache 稍后通过 Get 补上
+func (s *tinyLFUStore) Peek(key string) (value ByteView, ok bool) {
+	if v, ok := s.window.Peek(key); ok {
+		return v.(ByteView), true
+	}
+	if v, ok := s.main.Peek(key); ok {
+		return v.(ByteView), true
+	}
+	return
+}
+
+func (s *tinyLFUStore) Add(key string, value ByteView) {
+	s.sketch.Increment(key)
+	if _, ok := s.main.Get(key); ok {
+		s.main.Add(key, value)
+		return
+	}
+	s.window.Add(key, value)
+}
+
+func (s *tinyLFUStore) Remove(key string) {
+	s.removing = true
+	s.window.Remove(key)
+	s.main.Remove(key)
+	s.removing = false
+}
+
+// RemoveOldest 优先淘汰主缓存中最旧的条目，主缓存为空时淘汰窗口中的
+func (s *tinyLFUStore) RemoveOldest() {
+	c := s.main
+	if c.Len() == 0 {
+		c = s.window
+	}
+	if key, value, ok := c.GetOldest(); ok {
+		s.removing = true
+		c.RemoveOldest()
+		s.removing = false
+		s.evicted(key, value.(ByteView))
+	}
+}
+
+func (s *tinyLFUStore) Range(fn func(key string, value ByteView) bool) {
+	more := true
+	s.window.Range(func(key string, value lru.Value) bool {
+		more = fn(key, value.(ByteView))
+		return more
+	})
+	if !more {
+		return
+	}
+	s.main.Range(func(key string, value lru.Value) bool {
+		return fn(key, value.(ByteView))
+	})
+}
+
+func (s *tinyLFUStore) Len() int {
+	return s.window.Len() + s.main.Len()
+}
+
+func (s *tinyLFUStore) Bytes() int64 {
+	return s.window.Bytes() + s.main.Bytes()
+}
+
+func (s *tinyLFUStore) Evictions() int64 {
+	return s.evictions
+}
+
+func (s *tinyLFUStore) SetOnEvicted(fn func(key string, value ByteView)) {
+	s.onEvicted = fn
+}
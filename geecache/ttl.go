@@ -0,0 +1,98 @@
+package geecache
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// WithTTL makes values loaded by the group expire ttl after they are
+// cached. Expired values count as misses, and a background sweeper removes
+// them every ttl so their bytes are reclaimed before LRU eviction.
+func WithTTL(ttl time.Duration) GroupOption {
+	return func(g *Group) {
+		g.ttl = ttl
+	}
+}
+
+// expiry 返回 ttl 之后的过期时间，ttl 不大于 0 时返回零值，表示永不过期
+func expiry(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+// WithTTLJitter shortens each entry's TTL by a random amount of up to
+// percent (0-100) of it, so values cached together by a bulk warmup don't
+// all expire and reload at the same moment. Entries never outlive their
+// TTL. It applies to the group TTL and to explicit Set TTLs, but not to
+// expiry times returned by a GetterWithTTL.
+func WithTTLJitter(percent float64) GroupOption {
+	return func(g *Group) {
+		if percent < 0 {
+			percent = 0
+		} else if percent > 100 {
+			percent = 100
+		}
+		g.ttlJitter = percent / 100
+	}
+}
+
+// expiry 和包级的 expiry 一样，只是按 WithTTLJitter 随机缩短 ttl
+func (g *Group) expiry(ttl time.Duration) time.Time {
+	if ttl > 0 && g.ttlJitter > 0 {
+		ttl -= time.Duration(rand.Float64() * g.ttlJitter * float64(ttl))
+	}
+	return expiry(ttl)
+}
+
+// sweep 定期清理过期条目，直到 DestroyGroup 关闭 g.done
+func (g *Group) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			g.mainCache.removeExpired(now)
+			g.hotCache.removeExpired(now)
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// GetterWithTTL can be implemented by a getter whose data source decides
+// how long each value stays fresh, e.g. from an HTTP Cache-Control header.
+// A zero expireAt falls back to the group's TTL. Without WithTTL there is
+// no sweeper, and expired values are dropped when they are looked up.
+type GetterWithTTL interface {
+	GetWithTTL(ctx context.Context, key string) (value []byte, expireAt time.Time, err error)
+}
+
+// GetterWithTTLFunc implements GetterCtx and GetterWithTTL with a function,
+// so it can be passed to NewGroupContext.
+type GetterWithTTLFunc func(ctx context.Context, key string) ([]byte, time.Time, error)
+
+func (f GetterWithTTLFunc) Get(ctx context.Context, key string) ([]byte, error) {
+	b, _, err := f(ctx, key)
+	return b, err
+}
+
+func (f GetterWithTTLFunc) GetWithTTL(ctx context.Context, key string) ([]byte, time.Time, error) {
+	return f(ctx, key)
+}
+
+// getWithExpire 在 getter 实现了 GetterWithTTL 时一并取回过期时间
+func getWithExpire(ctx context.Context, getter GetterCtx, key string) ([]byte, time.Time, error) {
+	if a, ok := getter.(getterAdapter); ok {
+		if tg, ok := a.getter.(GetterWithTTL); ok {
+			return tg.GetWithTTL(ctx, key)
+		}
+	}
+	if tg, ok := getter.(GetterWithTTL); ok {
+		return tg.GetWithTTL(ctx, key)
+	}
+	b, err := getter.Get(ctx, key)
+	return b, time.Time{}, err
+}
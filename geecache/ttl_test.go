@@ -0,0 +1,123 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestTTL(t *testing.T) {
+	loads := 0
+	g := NewGroup("ttl", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(key), nil
+	}), WithTTL(30*time.Millisecond))
+
+	g.Get(context.Background(), "Tom")
+	g.Get(context.Background(), "Tom")
+	if loads != 1 {
+		t.Fatalf("second Get should hit the cache, loads = %d", loads)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatalf("Tom should have expired")
+	}
+	g.Get(context.Background(), "Tom")
+	if loads != 2 {
+		t.Fatalf("expired value should be reloaded, loads = %d", loads)
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	for name, fn := range map[string]StoreFunc{"lru": NewLRUStore, "lfu": NewLFUStore, "arc": NewARCStore, "tinylfu": NewTinyLFUStore(16), "slab": NewSlabStore(2)} {
+		c := cache{cacheBytes: 2 << 10, newStore: fn}
+		c.add("old", ByteView{b: []byte("1"), e: time.Now().Add(-time.Second)})
+		c.add("new", ByteView{b: []byte("2"), e: time.Now().Add(time.Hour)})
+		c.add("forever", ByteView{b: []byte("3")})
+		before, _, _ := c.stats()
+
+		if n := c.removeExpired(time.Now()); n != 1 {
+			t.Fatalf("%s: removed %d entries, want 1", name, n)
+		}
+		if bytes, items, _ := c.stats(); items != 2 || bytes >= before {
+			t.Fatalf("%s: expired entry should be reclaimed", name)
+		}
+		if v, ok := c.get("new"); !ok || v.Expire().IsZero() {
+			t.Fatalf("%s: unexpired entry should keep its expiry", name)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	loads := 0
+	g := NewGroup("set", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte("db"), nil
+	}))
+
+	b := []byte("fresh")
+	if err := g.Set("Tom", NewByteView(b), 30*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	b[0] = 'F'
+	if v, err := g.Get(context.Background(), "Tom"); err != nil || v.String() != "fresh" || loads != 0 {
+		t.Fatalf("Get after Set should return the stored value, got %q", v)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if v, _ := g.Get(context.Background(), "Tom"); v.String() != "db" || loads != 1 {
+		t.Fatalf("value set with a ttl should expire, got %q", v)
+	}
+}
+
+func TestGetterWithTTL(t *testing.T) {
+	loads := 0
+	g := NewGroupContext("getter-ttl", 2<<10, GetterWithTTLFunc(func(ctx context.Context, key string) ([]byte, time.Time, error) {
+		loads++
+		if key == "short" {
+			return []byte(key), time.Now().Add(20 * time.Millisecond), nil
+		}
+		return []byte(key), time.Time{}, nil
+	}), WithTTL(time.Hour))
+	ctx := context.Background()
+
+	g.Get(ctx, "short")
+	g.Get(ctx, "long")
+	if v, _ := g.mainCache.get("long"); time.Until(v.Expire()) < 59*time.Minute {
+		t.Fatalf("zero expireAt should fall back to the group TTL, got %v", v.Expire())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	g.Get(ctx, "short")
+	g.Get(ctx, "long")
+	if loads != 3 {
+		t.Fatalf("only the short-lived key should be reloaded, loads = %d", loads)
+	}
+}
+
+func TestTTLJitter(t *testing.T) {
+	ttl := time.Hour
+	g := NewGroup("ttl-jitter", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}), WithTTL(ttl), WithTTLJitter(20))
+	defer DestroyGroup("ttl-jitter")
+
+	begin := time.Now()
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		v, err := g.Get(context.Background(), fmt.Sprintf("key%d", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		d := v.Expire().Sub(begin).Round(time.Second)
+		if d > ttl || d < ttl*8/10-time.Second {
+			t.Fatalf("expiry %v outside [%v, %v]", d, ttl*8/10, ttl)
+		}
+		seen[d] = true
+	}
+	if len(seen) < 2 {
+		t.Fatalf("expiries should be spread out, got %v", seen)
+	}
+}
@@ -0,0 +1,65 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Version returns the current version of the group, see BumpVersion.
+func (g *Group) Version() int64 {
+	return atomic.LoadInt64(&g.version)
+}
+
+// maxVersionSkew 是其他节点发来的版本号最多可以超前本机时钟的时间
+const maxVersionSkew = time.Hour
+
+// BumpVersion moves the group's version forward here and on every peer.
+// Values cached under an older version are treated as misses from then on,
+// which invalidates the whole group at once without walking its entries,
+// e.g. after a schema change. The old entries are reclaimed by eviction.
+//
+// Versions are UnixNano timestamps, bumped by one if the clock hasn't moved
+// past the current version, so a node that restarted with version 0 still
+// sends a version newer than the ones its peers have seen.
+func (g *Group) BumpVersion(ctx context.Context) (int64, error) {
+	var v int64
+	for {
+		cur := atomic.LoadInt64(&g.version)
+		if v = time.Now().UnixNano(); v <= cur {
+			v = cur + 1
+		}
+		if atomic.CompareAndSwapInt64(&g.version, cur, v) {
+			break
+		}
+	}
+	g.negative.clear()
+	if g.peers == nil {
+		return v, nil
+	}
+	return v, g.forEachPeer(nil, func(peer PeerGetter) error {
+		if err := peer.SetVersion(ctx, g.name, v); err != nil {
+			return fmt.Errorf("set version of %s on %s: %w", g.name, peerName(peer), err)
+		}
+		return nil
+	})
+}
+
+// setVersion 处理其他节点广播的版本号，只会让版本前进，不会回退。
+// 负数和超前本机时钟太多的版本号会被拒绝，否则一个很大的值会让之后的 BumpVersion 都失效
+func (g *Group) setVersion(v int64) error {
+	if v < 0 || v > time.Now().Add(maxVersionSkew).UnixNano() {
+		return fmt.Errorf("version %d is not a recent timestamp", v)
+	}
+	for {
+		cur := atomic.LoadInt64(&g.version)
+		if v <= cur {
+			return nil
+		}
+		if atomic.CompareAndSwapInt64(&g.version, cur, v) {
+			g.negative.clear()
+			return nil
+		}
+	}
+}
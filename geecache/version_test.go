@@ -0,0 +1,83 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBumpVersion(t *testing.T) {
+	loads := 0
+	peer := &fakePeer{name: "p"}
+	g := NewGroup("version", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(fmt.Sprintf("%s-%d", key, loads)), nil
+	}), WithStore(NewSlabStore(1)))
+	g.RegisterPeers(&fakePicker{all: []*fakePeer{peer}})
+	ctx := context.Background()
+
+	g.Get(ctx, "Tom")
+	g.Set("Jack", NewByteView([]byte("589")), 0)
+	v, err := g.BumpVersion(ctx)
+	if err != nil || v <= 0 {
+		t.Fatalf("BumpVersion = %d, %v", v, err)
+	}
+	if len(peer.removed) != 1 || peer.removed[0] != fmt.Sprintf("version@%d", v) {
+		t.Fatalf("peers should receive the new version, got %v", peer.removed)
+	}
+	if v, _ := g.Get(ctx, "Tom"); v.String() != "Tom-2" {
+		t.Fatalf("values of the old version should be reloaded, got %q", v)
+	}
+	if _, ok := g.lookupCache("Jack"); ok {
+		t.Fatalf("Set values of the old version should be invalid too")
+	}
+	if v, _ := g.Get(ctx, "Tom"); v.String() != "Tom-2" {
+		t.Fatalf("reloaded value should be cached under the new version, got %q", v)
+	}
+}
+
+func TestHTTPSetVersion(t *testing.T) {
+	g := NewGroup("http-version", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	srv, peer := newPeerServer()
+	defer srv.Close()
+
+	if err := peer.SetVersion(context.Background(), "http-version", 5); err != nil {
+		t.Fatal(err)
+	}
+	peer.SetVersion(context.Background(), "http-version", 3)
+	if g.Version() != 5 {
+		t.Fatalf("version should move forward only, got %d", g.Version())
+	}
+	for _, v := range []int64{-1, math.MaxInt64, time.Now().Add(2 * maxVersionSkew).UnixNano()} {
+		if err := peer.SetVersion(context.Background(), "http-version", v); err == nil {
+			t.Fatalf("version %d should be rejected", v)
+		}
+	}
+	if g.Version() != 5 {
+		t.Fatalf("rejected versions should not change the version, got %d", g.Version())
+	}
+}
+
+func TestBumpVersionAfterRestart(t *testing.T) {
+	peer := NewGroup("version-restart-peer", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	if _, err := peer.BumpVersion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	// 重启的节点版本号从 0 开始，它发出的新版本仍然要比其他节点已有的更新
+	restarted := NewGroup("version-restart", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	v, _ := restarted.BumpVersion(context.Background())
+	if err := peer.setVersion(v); err != nil || peer.Version() != v {
+		t.Fatalf("peer should accept version %d from the restarted node, has %d (%v)", v, peer.Version(), err)
+	}
+	if v2, _ := restarted.BumpVersion(context.Background()); v2 <= v {
+		t.Fatalf("versions should keep increasing, got %d after %d", v2, v)
+	}
+}
@@ -0,0 +1,53 @@
+package geecache
+
+import (
+	"context"
+	"sync"
+)
+
+// Warm preloads keys that are not cached yet, e.g. at startup or after a
+// deploy, with at most parallelism loads in flight so the origin isn't
+// stampeded. Keys owned by peers are loaded through the peers as usual. It
+// keeps going when a key fails and returns the first error; it stops early
+// when ctx is done.
+func (g *Group) Warm(ctx context.Context, keys []string, parallelism int) error {
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+	)
+	sem := make(chan struct{}, parallelism)
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return ctx.Err()
+		}
+		wg.Add(1)
+		go func(key string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			if _, ok := g.lookupCache(key); ok {
+				return
+			}
+			if _, err := g.load(ctx, key); err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+			}
+		}(key)
+	}
+	wg.Wait()
+	return firstErr
+}
@@ -0,0 +1,43 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWarm(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	g := NewGroup("warm", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		mu.Lock()
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if key == "bad" {
+			return nil, fmt.Errorf("%s not exist", key)
+		}
+		return []byte(key), nil
+	}))
+
+	keys := []string{"k1", "k2", "k3", "bad", "k4", "k5", "k6"}
+	if err := g.Warm(context.Background(), keys, 2); err == nil {
+		t.Fatalf("Warm should report the failed key")
+	}
+	if peak > 2 {
+		t.Fatalf("at most 2 loads should run at once, got %d", peak)
+	}
+	for _, key := range keys {
+		if _, ok := g.mainCache.get(key); ok != (key != "bad") {
+			t.Fatalf("%s cached = %v after Warm", key, ok)
+		}
+	}
+}
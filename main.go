@@ -49,7 +49,7 @@ func startAPIServer(apiAddr string, gee *geecache.Group) {
 	http.Handle("/api", http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
 			key := r.URL.Query().Get("key")
-			view, err := gee.Get(key)
+			view, err := gee.Get(r.Context(), key)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return